@@ -0,0 +1,362 @@
+/*
+ * Rule-based computer players.
+ *
+ * All bots win whenever they can. Easy bots only look at the shanten number,
+ * and never claim anything but a win. Normal bots also count the tiles that
+ * improve their hand, declare kongs, and claim sets that bring them closer to
+ * winning. Hard bots additionally steer towards hands that score well under
+ * the rules being played: flushes, pungs of dragons and scoring winds, and
+ * waits on tiles that complete the highest-scoring hands.
+ */
+
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/sybrenstuvel/mahjong/game"
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+// Difficulty determines how well a bot plays.
+type Difficulty int
+
+// The difficulties of bots, from weak to strong.
+const (
+	Easy Difficulty = iota
+	Normal
+	Hard
+)
+
+// ErrUnknownDifficulty is returned when parsing an unknown difficulty.
+var ErrUnknownDifficulty = errors.New("unknown difficulty")
+
+var difficultyNames = map[Difficulty]string{
+	Easy:   "easy",
+	Normal: "normal",
+	Hard:   "hard",
+}
+
+func (difficulty Difficulty) String() string {
+	name, found := difficultyNames[difficulty]
+	if !found {
+		return fmt.Sprintf("Difficulty(%d)", int(difficulty))
+	}
+	return name
+}
+
+// MarshalJSON converts a difficulty to its name in JSON.
+func (difficulty Difficulty) MarshalJSON() ([]byte, error) {
+	return json.Marshal(difficulty.String())
+}
+
+// ParseDifficulty returns the difficulty with the given name.
+func ParseDifficulty(name string) (Difficulty, error) {
+	for difficulty, difficultyName := range difficultyNames {
+		if difficultyName == name {
+			return difficulty, nil
+		}
+	}
+	return Easy, fmt.Errorf("%q: %s", name, ErrUnknownDifficulty)
+}
+
+// RuleBased is a bot that decides with a few rules of thumb.
+type RuleBased struct {
+	Difficulty Difficulty
+	rng        *rand.Rand
+}
+
+// New returns a bot of the given difficulty. The seed determines the choices it
+// makes between equally good options.
+func New(difficulty Difficulty, seed int64) *RuleBased {
+	return &RuleBased{
+		Difficulty: difficulty,
+		rng:        rand.New(rand.NewSource(seed)),
+	}
+}
+
+func findAction(actions []game.Action, actionType game.ActionType) (game.Action, bool) {
+	for _, action := range actions {
+		if action.Type == actionType {
+			return action, true
+		}
+	}
+	return game.Action{}, false
+}
+
+// Turn wins when possible, declares kongs that don't set the hand back, and
+// otherwise discards the least useful tile.
+func (b *RuleBased) Turn(view *game.View, actions []game.Action) game.Action {
+	if win, ok := findAction(actions, game.ActionWin); ok {
+		return win
+	}
+
+	if b.Difficulty > Easy {
+		current := bestShanten(view.Player.Concealed, len(view.Player.Melds))
+		for _, action := range actions {
+			if action.Type != game.ActionKong {
+				continue
+			}
+			concealed, melds := afterKong(&view.Player, action.Tile)
+			if score.ShantenTiles(concealed, melds) <= current {
+				return action
+			}
+		}
+	}
+
+	return game.Action{Seat: view.Seat, Type: game.ActionDiscard, Tile: b.chooseDiscard(view)}
+}
+
+// Claim wins when possible, and claims a set when that brings the hand closer to winning.
+func (b *RuleBased) Claim(view *game.View, actions []game.Action) game.Action {
+	if win, ok := findAction(actions, game.ActionWin); ok {
+		return win
+	}
+	pass := game.Action{Seat: view.Seat, Type: game.ActionPass}
+	if b.Difficulty == Easy {
+		return pass
+	}
+
+	current := score.ShantenTiles(view.Player.Concealed, len(view.Player.Melds))
+	best, bestShantenAfter := pass, current
+	for _, action := range actions {
+		set := claimedSet(action, view.ClaimTile)
+		if set == nil {
+			continue
+		}
+		if b.Difficulty == Hard && !b.worthClaiming(view, set) {
+			continue
+		}
+
+		concealed := withoutTiles(view.Player.Concealed, set, view.ClaimTile)
+		after := bestShanten(concealed, len(view.Player.Melds)+1)
+		if action.Type == game.ActionKong {
+			// A kong gets a replacement tile, rather than needing a discard.
+			after = score.ShantenTiles(concealed, len(view.Player.Melds)+1)
+		}
+		if after < bestShantenAfter {
+			best, bestShantenAfter = action, after
+		}
+	}
+	return best
+}
+
+// claimedSet returns the tiles of the set the action claims, or nil when it doesn't claim a set.
+func claimedSet(action game.Action, tile score.Tile) []score.Tile {
+	switch action.Type {
+	case game.ActionChow:
+		return []score.Tile{action.Tile, action.Tile + 1, action.Tile + 2}
+	case game.ActionPung:
+		return []score.Tile{tile, tile, tile}
+	case game.ActionKong:
+		return []score.Tile{tile, tile, tile, tile}
+	}
+	return nil
+}
+
+// withoutTiles returns the concealed tiles minus those of the set, except the claimed tile.
+func withoutTiles(concealed, set []score.Tile, claimed score.Tile) []score.Tile {
+	rest := append([]score.Tile{}, concealed...)
+	skippedClaimed := false
+	for _, tile := range set {
+		if tile == claimed && !skippedClaimed {
+			skippedClaimed = true
+			continue
+		}
+		for idx, held := range rest {
+			if held == tile {
+				rest = append(rest[:idx], rest[idx+1:]...)
+				break
+			}
+		}
+	}
+	return rest
+}
+
+// afterKong returns the concealed tiles and number of melds after declaring a kong of the tile.
+func afterKong(player *game.Player, tile score.Tile) ([]score.Tile, int) {
+	rest := []score.Tile{}
+	for _, held := range player.Concealed {
+		if held != tile {
+			rest = append(rest, held)
+		}
+	}
+	if len(player.Concealed)-len(rest) == 4 {
+		return rest, len(player.Melds) + 1
+	}
+	// Adding the tile to a melded pung doesn't add a meld.
+	return rest, len(player.Melds)
+}
+
+// bestShanten returns the lowest shanten that can be reached by discarding one of the tiles.
+func bestShanten(concealed []score.Tile, melds int) int {
+	best := score.ShantenTiles(concealed, melds)
+	for idx := range concealed {
+		rest := append(append([]score.Tile{}, concealed[:idx]...), concealed[idx+1:]...)
+		if shanten := score.ShantenTiles(rest, melds); shanten < best {
+			best = shanten
+		}
+	}
+	return best
+}
+
+// chooseDiscard picks the tile to discard.
+func (b *RuleBased) chooseDiscard(view *game.View) score.Tile {
+	hand := view.Hand()
+	advice, err := score.Advise(&hand, view.Visible)
+	if err != nil || len(advice) == 0 {
+		// Tiles dealt from a wall always get advice, but never leave the turn hanging.
+		return view.Player.Concealed[len(view.Player.Concealed)-1]
+	}
+
+	// Advice is sorted by shanten first, so the best options come first.
+	candidates := []score.Advice{}
+	for _, option := range advice {
+		if option.Shanten == advice[0].Shanten {
+			candidates = append(candidates, option)
+		}
+	}
+
+	switch b.Difficulty {
+	case Easy:
+		return candidates[b.rng.Intn(len(candidates))].Discard
+	case Normal:
+		return candidates[0].Discard
+	}
+
+	best, bestValue := candidates[0].Discard, -1.0
+	for _, option := range candidates {
+		if value := b.value(view, option); value > bestValue {
+			best, bestValue = option.Discard, value
+		}
+	}
+	return best
+}
+
+// value estimates what the hand is worth after the discard, under the rules being played.
+func (b *RuleBased) value(view *game.View, option score.Advice) float64 {
+	rest := withoutTiles(view.Player.Concealed, []score.Tile{option.Discard}, score.NoTile)
+	if option.Shanten > 0 {
+		return float64(option.Live) * potential(view, rest)
+	}
+
+	// The hand is ready, so we know exactly what every wait is worth.
+	after := *view
+	after.Player.Concealed = rest
+	hand := after.Hand()
+	seen := seenTiles(view)
+	total := 0.0
+	for _, wait := range view.Rules.Waits(&hand) {
+		if live := 4 - seen[wait.Tile]; live > 0 {
+			total += float64(live * wait.Score)
+		}
+	}
+	return total
+}
+
+func seenTiles(view *game.View) map[score.Tile]int {
+	seen := map[score.Tile]int{}
+	for _, tile := range view.Visible {
+		seen[tile]++
+	}
+	for _, tile := range view.Player.Concealed {
+		seen[tile]++
+	}
+	for _, meld := range view.Player.Melds {
+		for _, tile := range meld.Tiles {
+			seen[tile]++
+		}
+	}
+	return seen
+}
+
+// potential is a multiplier for how promising the tiles are for a high score.
+func potential(view *game.View, concealed []score.Tile) float64 {
+	tiles := append([]score.Tile{}, concealed...)
+	for _, meld := range view.Player.Melds {
+		tiles = append(tiles, meld.Tiles...)
+	}
+
+	multiplier := 1.0
+	rules := view.Rules
+
+	// Flushes double the score, so stay in one suit when the hand is close to it.
+	suits := map[score.Tile]int{}
+	honours := 0
+	for _, tile := range tiles {
+		if tile.IsHonour() {
+			honours++
+		} else {
+			suits[tile.Suit()]++
+		}
+	}
+	most := 0
+	for _, count := range suits {
+		if count > most {
+			most = count
+		}
+	}
+	others := len(tiles) - honours - most
+	_, fullFlush := rules.Detectors["full flush"]
+	_, halfFlush := rules.Detectors["half-flush"]
+	switch {
+	case others == 0 && honours == 0 && fullFlush:
+		multiplier *= 4
+	case others == 0 && (halfFlush || fullFlush):
+		multiplier *= 2
+	case others <= 2 && most >= 7 && (halfFlush || fullFlush):
+		multiplier *= 1.5
+	}
+
+	// Pairs and pungs of dragons and scoring winds can become doubling pungs.
+	counts := map[score.Tile]int{}
+	for _, tile := range tiles {
+		counts[tile]++
+	}
+	for tile, count := range counts {
+		if count < 2 {
+			continue
+		}
+		doubles := 0
+		switch {
+		case tile.IsDragon():
+			doubles = rules.Points.DragonPung
+		case tile == view.Player.Wind || tile == view.RoundWind:
+			doubles = rules.Points.WindPung
+		}
+		for ; doubles > 0; doubles-- {
+			multiplier *= 1.5
+		}
+	}
+	return multiplier
+}
+
+// worthClaiming returns true when the claimed set doesn't spoil the hand's prospects.
+func (b *RuleBased) worthClaiming(view *game.View, set []score.Tile) bool {
+	tile := set[0]
+	if tile.IsHonour() {
+		// Claiming a pung of honours never breaks a flush.
+		return true
+	}
+
+	// Only claim suited sets when the hand goes for a flush in that suit, or when
+	// it's getting late and speed matters more than score.
+	if view.WallRemaining < 30 {
+		return true
+	}
+	suits := map[score.Tile]int{}
+	for _, held := range view.Player.Concealed {
+		if !held.IsHonour() {
+			suits[held.Suit()]++
+		}
+	}
+	for suit, count := range suits {
+		if suit != tile.Suit() && count > suits[tile.Suit()] {
+			return false
+		}
+	}
+	return suits[tile.Suit()] >= 6
+}
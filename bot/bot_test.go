@@ -0,0 +1,130 @@
+package bot
+
+import (
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+
+	"github.com/sybrenstuvel/mahjong/game"
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/wall"
+)
+
+type BotTestSuite struct{}
+
+var _ = check.Suite(&BotTestSuite{})
+
+func mustParseTiles(c *check.C, notation string) []score.Tile {
+	tiles, err := score.ParseTiles(notation)
+	if err != nil {
+		c.Fatalf("unable to parse %q: %s", notation, err)
+	}
+	return tiles
+}
+
+// view returns the view of seat 0, holding the concealed tiles, at the start of the wall.
+func view(c *check.C, concealed string) *game.View {
+	return &game.View{
+		Seat:          0,
+		Rules:         score.DefaultRuleSet(),
+		RoundWind:     score.WindEast,
+		Player:        game.Player{Wind: score.WindEast, Concealed: mustParseTiles(c, concealed)},
+		Visible:       []score.Tile{},
+		WallRemaining: 80,
+		ClaimTile:     score.NoTile,
+		Discarder:     -1,
+	}
+}
+
+func (s *BotTestSuite) TestParseDifficulty(c *check.C) {
+	difficulty, err := ParseDifficulty("hard")
+	assert.Nil(c, err)
+	assert.Equal(c, Hard, difficulty)
+
+	_, err = ParseDifficulty("impossible")
+	assert.Contains(c, err.Error(), ErrUnknownDifficulty.Error())
+}
+
+func (s *BotTestSuite) TestWinWhenPossible(c *check.C) {
+	v := view(c, "123b 456b 789b 11c 234s")
+	actions := []game.Action{
+		{Seat: 0, Type: game.ActionWin},
+		{Seat: 0, Type: game.ActionDiscard, Tile: score.Balls1},
+	}
+	for _, difficulty := range []Difficulty{Easy, Normal, Hard} {
+		assert.Equal(c, actions[0], New(difficulty, 1).Turn(v, actions))
+	}
+}
+
+func (s *BotTestSuite) TestDiscardIsolatedTile(c *check.C) {
+	v := view(c, "123b 456b 789b 11c 23s N")
+	for _, difficulty := range []Difficulty{Easy, Normal, Hard} {
+		action := New(difficulty, 1).Turn(v, nil)
+		assert.Equal(c, game.Action{Seat: 0, Type: game.ActionDiscard, Tile: score.WindNorth}, action,
+			"difficulty %s", difficulty)
+	}
+}
+
+func (s *BotTestSuite) TestClaim(c *check.C) {
+	v := view(c, "123b 456b 79b 11c 23s NN")
+	v.ClaimTile, v.Discarder = score.WindNorth, 3
+	actions := []game.Action{
+		{Seat: 0, Type: game.ActionPass},
+		{Seat: 0, Type: game.ActionPung},
+	}
+
+	assert.Equal(c, actions[0], New(Easy, 1).Claim(v, actions))
+	assert.Equal(c, actions[1], New(Normal, 1).Claim(v, actions))
+	assert.Equal(c, actions[1], New(Hard, 1).Claim(v, actions))
+
+	// A pung that doesn't help isn't claimed.
+	v = view(c, "123b 456b 789b 11c 23s N")
+	v.ClaimTile, v.Discarder = score.Chars1, 3
+	assert.Equal(c, actions[0], New(Normal, 1).Claim(v, actions))
+}
+
+func (s *BotTestSuite) TestValueOfWaits(c *check.C) {
+	// Both hands wait on two pairs, but a pung of dragons doubles the score.
+	dragons := view(c, "123b 456b 789b rr 55c 3s")
+	plain := view(c, "123b 456b 789b 22c 55c 3s")
+	option := score.Advice{Discard: score.Bamboo3, Shanten: 0}
+
+	b := New(Hard, 1)
+	assert.True(c, b.value(dragons, option) > b.value(plain, option))
+
+	// Waits on tiles that are all visible are worth nothing.
+	plain.Visible = mustParseTiles(c, "22c 55c")
+	assert.Equal(c, 0.0, b.value(plain, option))
+}
+
+func (s *BotTestSuite) TestPotentialOfFlush(c *check.C) {
+	flush := view(c, "123b 456b 789b 11b 23b N")
+	mixed := view(c, "123b 456c 789s 11b 23b N")
+	assert.True(c, potential(flush, flush.Player.Concealed) > potential(mixed, mixed.Player.Concealed))
+}
+
+func (s *BotTestSuite) TestPlayMatch(c *check.C) {
+	rules := score.DefaultRuleSet().Copy("rotating")
+	rules.Dealer = score.DealerRules{}
+	m := game.NewMatch(rules, [score.NumPlayers]string{"easy", "normal", "hard", "hard"}, 1)
+	for seat, difficulty := range []Difficulty{Easy, Normal, Hard, Hard} {
+		m.SetBot(seat, New(difficulty, int64(seat)))
+	}
+
+	wins := 0
+	for !m.Finished {
+		g, err := m.StartHand()
+		if !assert.Nil(c, err) {
+			c.FailNow()
+		}
+		// Bots play the whole hand by themselves.
+		assert.True(c, g.Finished())
+		assert.True(c, g.WallRemaining() < wall.Size)
+
+		record, err := m.FinishHand()
+		assert.Nil(c, err)
+		if record.Result.Winner >= 0 {
+			wins++
+		}
+	}
+	assert.True(c, wins > 0, "bots never won")
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sybrenstuvel/mahjong/bot"
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/simulate"
+)
+
+var cliArgs struct {
+	hands   int
+	workers int
+	seed    int64
+	ruleSet string
+	rules   string
+	bots    string
+	bucket  int
+	json    bool
+}
+
+func parseCliArgs() {
+	flag.IntVar(&cliArgs.hands, "hands", 1000, "Number of hands to play.")
+	flag.IntVar(&cliArgs.workers, "workers", runtime.NumCPU(), "Number of hands to play in parallel.")
+	flag.Int64Var(&cliArgs.seed, "seed", 1, "Seed of the walls; the same seed gives the same statistics.")
+	flag.StringVar(&cliArgs.ruleSet, "ruleset", score.DefaultRuleSetName, "Name of the rule set to play with.")
+	flag.StringVar(&cliArgs.rules, "rules", "", "YAML file with house rules to play with, instead of -ruleset.")
+	flag.StringVar(&cliArgs.bots, "bots", "normal", "Difficulty of the bots; one for all seats, or four separated by commas.")
+	flag.IntVar(&cliArgs.bucket, "bucket", 16, "Highest score in the first bucket of the score distribution.")
+	flag.BoolVar(&cliArgs.json, "json", false, "Report the statistics as JSON.")
+	flag.Parse()
+}
+
+// parseBots returns the difficulty of each seat.
+func parseBots(arg string) ([score.NumPlayers]bot.Difficulty, error) {
+	bots := [score.NumPlayers]bot.Difficulty{}
+	names := strings.Split(arg, ",")
+	if len(names) == 1 {
+		names = []string{names[0], names[0], names[0], names[0]}
+	}
+	if len(names) != score.NumPlayers {
+		return bots, fmt.Errorf("need 1 or %d difficulties, not %d", score.NumPlayers, len(names))
+	}
+	for seat, name := range names {
+		difficulty, err := bot.ParseDifficulty(strings.TrimSpace(name))
+		if err != nil {
+			return bots, err
+		}
+		bots[seat] = difficulty
+	}
+	return bots, nil
+}
+
+func loadRules() (*score.RuleSet, error) {
+	if cliArgs.rules != "" {
+		return score.LoadRuleSet(cliArgs.rules)
+	}
+	return score.LookupRuleSet(cliArgs.ruleSet)
+}
+
+// sortedCounts returns the names ordered by count, most frequent first.
+func sortedCounts(counts map[string]int) []string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func percentage(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(count) / float64(total)
+}
+
+func report(stats *simulate.Stats, bots [score.NumPlayers]bot.Difficulty, duration time.Duration) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "Rule set:\t%s\n", stats.RuleSet)
+	fmt.Fprintf(w, "Bots:\t%v\n", bots)
+	fmt.Fprintf(w, "Hands played:\t%d\t(%.1f per second)\n", stats.Hands, float64(stats.Hands)/duration.Seconds())
+	fmt.Fprintf(w, "Won:\t%d\t%.1f%%\n", stats.Wins, percentage(stats.Wins, stats.Hands))
+	fmt.Fprintf(w, "Self-drawn:\t%d\t%.1f%% of wins\n", stats.SelfDrawn, percentage(stats.SelfDrawn, stats.Wins))
+	fmt.Fprintf(w, "Draw rate:\t%d\t%.1f%%\n", stats.Draws, 100*stats.DrawRate())
+	fmt.Fprintf(w, "Average score:\t%.1f\n", stats.AverageScore())
+	fmt.Fprintf(w, "Highest score:\t%d\n", stats.MaxScore)
+	fmt.Fprintf(w, "Wins per seat:\t%v\n", stats.SeatWins)
+
+	sections := []struct {
+		title  string
+		counts map[string]int
+	}{
+		{"Doubles", stats.Detectors},
+		{"Points", stats.Points},
+		{"Limit hands", stats.LimitHands},
+	}
+	for _, section := range sections {
+		fmt.Fprintf(w, "\n%s\tWins\tOf wins\n", section.title)
+		for _, name := range sortedCounts(section.counts) {
+			count := section.counts[name]
+			fmt.Fprintf(w, "  %s\t%d\t%.1f%%\n", name, count, percentage(count, stats.Wins))
+		}
+	}
+
+	fmt.Fprintf(w, "\nScore\tWins\tOf wins\n")
+	low := 0
+	for _, bucket := range stats.Distribution(cliArgs.bucket) {
+		fmt.Fprintf(w, "  %d-%d\t%d\t%.1f%%\n", low, bucket.Max, bucket.Count, percentage(bucket.Count, stats.Wins))
+		low = bucket.Max + 1
+	}
+}
+
+func main() {
+	parseCliArgs()
+	log.SetLevel(log.WarnLevel)
+
+	rules, err := loadRules()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	bots, err := parseBots(cliArgs.bots)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid -bots:", err)
+		os.Exit(2)
+	}
+
+	start := time.Now()
+	stats, err := simulate.Run(simulate.Options{
+		Rules:   rules,
+		Hands:   cliArgs.hands,
+		Seed:    cliArgs.seed,
+		Workers: cliArgs.workers,
+		Bots:    bots,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if !cliArgs.json {
+		report(stats, bots, time.Since(start))
+		return
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(struct {
+		*simulate.Stats
+		DrawRate     float64           `json:"draw_rate"`
+		AverageScore float64           `json:"average_score"`
+		Distribution []simulate.Bucket `json:"distribution"`
+	}{stats, stats.DrawRate(), stats.AverageScore(), stats.Distribution(cliArgs.bucket)})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
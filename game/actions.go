@@ -0,0 +1,201 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+// ActionType is what a player wants to do.
+type ActionType int
+
+// The things a player can do. Kongs and wins can be declared on the player's own
+// turn as well as claimed on another player's discard.
+const (
+	ActionPass    ActionType = iota // Don't claim the discard.
+	ActionDiscard                   // Discard a tile on your own turn.
+	ActionChow                      // Claim the discard for a chow; only for the next player.
+	ActionPung                      // Claim the discard for a pung.
+	ActionKong                      // Claim the discard for a kong, or declare one on your own turn.
+	ActionWin                       // Claim the discard to win, or declare a self-drawn win.
+)
+
+var actionTypeNames = map[ActionType]string{
+	ActionPass:    "pass",
+	ActionDiscard: "discard",
+	ActionChow:    "chow",
+	ActionPung:    "pung",
+	ActionKong:    "kong",
+	ActionWin:     "win",
+}
+
+func (actionType ActionType) String() string {
+	name, found := actionTypeNames[actionType]
+	if !found {
+		return fmt.Sprintf("ActionType(%d)", int(actionType))
+	}
+	return name
+}
+
+// MarshalJSON converts an action type to its name in JSON.
+func (actionType ActionType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(actionType.String())
+}
+
+// UnmarshalJSON reads an action type from its name in JSON.
+func (actionType *ActionType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	for candidate, candidateName := range actionTypeNames {
+		if candidateName == name {
+			*actionType = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown action %q", name)
+}
+
+// priority determines which claim on a discard wins.
+func (actionType ActionType) priority() int {
+	switch actionType {
+	case ActionWin:
+		return 3
+	case ActionPung, ActionKong:
+		return 2
+	case ActionChow:
+		return 1
+	}
+	return 0
+}
+
+// Action is something a player wants to do.
+type Action struct {
+	Seat int        `json:"seat"`
+	Type ActionType `json:"type"`
+	// The tile to discard or to declare a kong of, or the first tile of a chow.
+	Tile score.Tile `json:"tile,omitempty"`
+}
+
+func (a Action) String() string {
+	if a.Tile == score.NoTile {
+		return fmt.Sprintf("seat %d: %s", a.Seat, a.Type)
+	}
+	return fmt.Sprintf("seat %d: %s %s", a.Seat, a.Type, score.FormatTiles([]score.Tile{a.Tile}))
+}
+
+// ValidActions returns everything the seat can do right now. Nothing can be done by a
+// seat whose turn it isn't, or that already responded to the discard.
+func (g *Game) ValidActions(seat int) []Action {
+	if seat < 0 || seat >= score.NumPlayers {
+		return nil
+	}
+	switch g.phase {
+	case PhaseDiscard:
+		if seat != g.turn {
+			return nil
+		}
+		return g.turnActions(seat)
+	case PhaseClaim:
+		if _, responded := g.responses[seat]; responded || seat == g.discarder {
+			return nil
+		}
+		return g.claimActions(seat)
+	}
+	return nil
+}
+
+// DefaultAction returns what the seat does when it doesn't act in time: it passes on
+// a discard, and on its own turn discards the tile it drew. Returns false when the
+// seat has nothing to do.
+func (g *Game) DefaultAction(seat int) (Action, bool) {
+	actions := g.ValidActions(seat)
+	if len(actions) == 0 {
+		return Action{}, false
+	}
+	if g.phase == PhaseClaim {
+		return Action{seat, ActionPass, score.NoTile}, true
+	}
+
+	tile := g.drawn
+	if tile == score.NoTile {
+		// Nothing was drawn after claiming a discard, or by the dealer at the start.
+		concealed := g.players[seat].Concealed
+		tile = concealed[len(concealed)-1]
+	}
+	return Action{seat, ActionDiscard, tile}, true
+}
+
+// turnActions returns what the player can do on their own turn.
+func (g *Game) turnActions(seat int) []Action {
+	player := g.players[seat]
+	actions := []Action{}
+	if player.isWinning() {
+		actions = append(actions, Action{seat, ActionWin, score.NoTile})
+	}
+
+	for idx, tile := range player.Concealed {
+		if idx > 0 && player.Concealed[idx-1] == tile {
+			continue
+		}
+		if player.count(tile) == 4 || player.meldedPung(tile) >= 0 {
+			actions = append(actions, Action{seat, ActionKong, tile})
+		}
+	}
+	for idx, tile := range player.Concealed {
+		if idx > 0 && player.Concealed[idx-1] == tile {
+			continue
+		}
+		actions = append(actions, Action{seat, ActionDiscard, tile})
+	}
+	return actions
+}
+
+// claimActions returns what the player can claim the discard for.
+func (g *Game) claimActions(seat int) []Action {
+	player := g.players[seat]
+	tile := g.claimTile
+	actions := []Action{Action{seat, ActionPass, score.NoTile}}
+
+	player.add(tile)
+	winning := player.isWinning()
+	player.remove(tile)
+	if winning {
+		actions = append(actions, Action{seat, ActionWin, score.NoTile})
+	}
+	// A tile added to a pung can only be robbed to win.
+	if g.robbing {
+		return actions
+	}
+
+	count := player.count(tile)
+	if count >= 2 {
+		actions = append(actions, Action{seat, ActionPung, score.NoTile})
+	}
+	if count >= 3 {
+		actions = append(actions, Action{seat, ActionKong, score.NoTile})
+	}
+
+	if seat != next(g.discarder) || tile.Number() == 0 {
+		return actions
+	}
+	for start := tile - 2; start <= tile; start++ {
+		first := tile.Number() - int(tile-start)
+		if first < 1 || first > 7 {
+			continue
+		}
+		chow := []score.Tile{start, start + 1, start + 2}
+		others := []score.Tile{}
+		for _, chowTile := range chow {
+			if chowTile != tile {
+				others = append(others, chowTile)
+			}
+		}
+		if player.has(others...) {
+			actions = append(actions, Action{seat, ActionChow, start})
+		}
+	}
+	return actions
+}
@@ -0,0 +1,117 @@
+/*
+ * Computer players.
+ *
+ * Any seat can be played by a bot. Whenever the game waits for a seat that
+ * has a bot, it asks the bot for a decision, until it waits for a human
+ * player again or the hand is over.
+ */
+
+package game
+
+import "github.com/sybrenstuvel/mahjong/score"
+
+// View is what a seat knows about the hand; it is all a bot gets to decide with.
+type View struct {
+	Seat          int
+	Rules         *score.RuleSet
+	RoundWind     score.Tile
+	Player        Player       // A copy of the seat's own state.
+	Visible       []score.Tile // See Game.VisibleTiles().
+	WallRemaining int
+	ClaimTile     score.Tile // The tile that can be claimed, or NoTile on the seat's own turn.
+	Discarder     int        // Who discarded the claim tile, or -1.
+}
+
+// Hand returns the seat's tiles as a hand, with the concealed tiles in one set.
+func (v *View) Hand() score.Hand {
+	sets := append([]score.Set{}, v.Player.Melds...)
+	sets = append(sets, score.Set{Tiles: append([]score.Tile{}, v.Player.Concealed...), Concealed: true})
+	return score.Hand{
+		Sets:      sets,
+		Bonus:     v.Player.Bonus,
+		WindOwn:   v.Player.Wind,
+		WindRound: v.RoundWind,
+	}
+}
+
+// Bot decides for a seat. The action it returns must be one of the given actions;
+// anything else is replaced by the default action of the seat.
+type Bot interface {
+	// Turn chooses what to do on the seat's own turn: declare a win, declare a kong, or discard.
+	Turn(view *View, actions []Action) Action
+	// Claim chooses whether to claim the discard, to win or for a set, or to pass.
+	Claim(view *View, actions []Action) Action
+}
+
+// View returns what the seat knows about the hand.
+func (g *Game) View(seat int) *View {
+	player := g.players[seat]
+	view := &View{
+		Seat:      seat,
+		Rules:     g.rules,
+		RoundWind: g.roundWind,
+		Player: Player{
+			Wind:      player.Wind,
+			Concealed: append([]score.Tile{}, player.Concealed...),
+			Melds:     append([]score.Set{}, player.Melds...),
+			Bonus:     append([]score.Tile{}, player.Bonus...),
+			Discards:  append([]score.Tile{}, player.Discards...),
+		},
+		Visible:       g.VisibleTiles(seat),
+		WallRemaining: g.wall.Remaining(),
+		ClaimTile:     score.NoTile,
+		Discarder:     -1,
+	}
+	if g.phase == PhaseClaim && seat != g.discarder {
+		view.ClaimTile, view.Discarder = g.claimTile, g.discarder
+	}
+	return view
+}
+
+// SetBot lets the bot play the seat; nil gives the seat back to a human player.
+// The bot immediately acts when the game is waiting for the seat.
+func (g *Game) SetBot(seat int, bot Bot) {
+	g.bots[seat] = bot
+	g.playBots()
+}
+
+// playBots lets the bots act until the game waits for somebody else.
+func (g *Game) playBots() {
+	for g.phase != PhaseFinished {
+		acted := false
+		for seat, bot := range g.bots {
+			if bot == nil {
+				continue
+			}
+			actions := g.ValidActions(seat)
+			if len(actions) == 0 {
+				continue
+			}
+
+			var action Action
+			if g.phase == PhaseClaim {
+				action = bot.Claim(g.View(seat), actions)
+			} else {
+				action = bot.Turn(g.View(seat), actions)
+			}
+			if !containsAction(actions, action) {
+				action, _ = g.DefaultAction(seat)
+			}
+			g.perform(action)
+			acted = true
+			break
+		}
+		if !acted {
+			return
+		}
+	}
+}
+
+func containsAction(actions []Action, action Action) bool {
+	for _, candidate := range actions {
+		if candidate == action {
+			return true
+		}
+	}
+	return false
+}
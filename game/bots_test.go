@@ -0,0 +1,64 @@
+package game
+
+import (
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/wall"
+)
+
+type BotsTestSuite struct{}
+
+var _ = check.Suite(&BotsTestSuite{})
+
+// passingBot never claims, and discards whatever it's told; a bad choice is
+// replaced by the default action.
+type passingBot struct {
+	discard score.Tile
+	turns   int
+}
+
+func (b *passingBot) Turn(view *View, actions []Action) Action {
+	b.turns++
+	return Action{Seat: view.Seat, Type: ActionDiscard, Tile: b.discard}
+}
+
+func (b *passingBot) Claim(view *View, actions []Action) Action {
+	return Action{Seat: view.Seat, Type: ActionPass}
+}
+
+func (s *BotsTestSuite) TestBotsPlayTheirSeats(c *check.C) {
+	g := New(score.DefaultRuleSet(), wall.New(1), 0, score.WindEast)
+	bots := [score.NumPlayers]*passingBot{}
+	for seat := 1; seat < score.NumPlayers; seat++ {
+		bots[seat] = &passingBot{discard: score.NoTile}
+		g.SetBot(seat, bots[seat])
+	}
+
+	// The dealer is human, so the bots wait.
+	assert.Equal(c, 0, bots[1].turns)
+	for !g.Finished() {
+		action, _ := g.DefaultAction(0)
+		mustApply(c, g, action)
+		assert.True(c, g.Finished() || len(g.ValidActions(0)) > 0, "game waits for a bot")
+	}
+	assert.True(c, bots[1].turns > 0)
+	assert.Equal(c, wall.Size, countTiles(g))
+}
+
+func (s *BotsTestSuite) TestView(c *check.C) {
+	g := New(score.DefaultRuleSet(), wall.New(1), 0, score.WindEast)
+	view := g.View(1)
+	assert.Equal(c, g.Player(1).Concealed, view.Player.Concealed)
+	assert.Equal(c, score.WindSouth, view.Player.Wind)
+	assert.Equal(c, score.NoTile, view.ClaimTile)
+
+	// The view is a copy.
+	view.Player.Concealed[0] = score.NoTile
+	assert.NotEqual(c, score.NoTile, g.Player(1).Concealed[0])
+
+	hand := view.Hand()
+	assert.Equal(c, score.WindEast, hand.WindRound)
+	assert.Len(c, hand.Sets, 1)
+}
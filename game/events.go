@@ -0,0 +1,66 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+// EventType says what happened at the table.
+type EventType int
+
+// The things that can happen at the table.
+const (
+	EventDeal      EventType = iota // The seat was dealt its tiles. Private.
+	EventDraw                       // The seat drew a tile from the wall. Private.
+	EventBonus                      // The seat exposed a flower or season.
+	EventDiscard                    // The seat discarded a tile.
+	EventClaim                      // The seat claimed the discard, and melded the set.
+	EventKong                       // The seat declared a kong from its own tiles.
+	EventWin                        // The seat won the hand.
+	EventExhausted                  // The wall ran out, and nobody won.
+)
+
+var eventTypeNames = map[EventType]string{
+	EventDeal:      "deal",
+	EventDraw:      "draw",
+	EventBonus:     "bonus",
+	EventDiscard:   "discard",
+	EventClaim:     "claim",
+	EventKong:      "kong",
+	EventWin:       "win",
+	EventExhausted: "exhausted",
+}
+
+func (eventType EventType) String() string {
+	name, found := eventTypeNames[eventType]
+	if !found {
+		return fmt.Sprintf("EventType(%d)", int(eventType))
+	}
+	return name
+}
+
+// MarshalJSON converts an event type to its name in JSON.
+func (eventType EventType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(eventType.String())
+}
+
+// Event is something that happened at the table. Private events are only
+// fully visible to the seat they're about.
+type Event struct {
+	Type        EventType    `json:"type"`
+	Seat        int          `json:"seat"`
+	Tiles       []score.Tile `json:"tiles"`
+	Replacement bool         `json:"replacement,omitempty"` // The tile was drawn from the dead wall.
+	Private     bool         `json:"private,omitempty"`
+}
+
+// For returns the event as the given seat may see it; the tiles of another
+// seat's private event are hidden.
+func (e Event) For(seat int) Event {
+	if e.Private && e.Seat != seat {
+		e.Tiles = nil
+	}
+	return e
+}
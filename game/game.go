@@ -0,0 +1,500 @@
+/*
+ * A single hand of play, as a state machine.
+ *
+ * The game deals the tiles, and then waits for actions of the players. On
+ * their turn, players discard or declare a kong or a win; after a discard,
+ * the other players may claim it. Drawing tiles, replacing flowers and
+ * seasons, and resolving competing claims happen automatically. Once somebody
+ * wins or the wall runs out, the hands are scored and settled.
+ */
+
+package game
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/wall"
+)
+
+// Phase is what the game is waiting for.
+type Phase int
+
+// The phases of a hand.
+const (
+	PhaseDiscard  Phase = iota // The player whose turn it is has drawn, and must discard.
+	PhaseClaim                 // The other players may claim the discard.
+	PhaseFinished              // Somebody won, or the wall ran out.
+)
+
+// Standard errors.
+var (
+	ErrInvalidAction = errors.New("action not allowed")
+	ErrGameFinished  = errors.New("game is finished")
+)
+
+// Result is the outcome of a hand.
+type Result struct {
+	Winner    int                   `json:"winner"`    // -1 when the wall ran out.
+	Discarder int                   `json:"discarder"` // -1 when self-drawn, or when nobody won.
+	Hand      *score.Hand           `json:"hand"`      // The winning hand, or nil when nobody won.
+	Breakdown *score.Breakdown      `json:"breakdown"` // The score of the winning hand, or nil when nobody won.
+	Scores    [score.NumPlayers]int `json:"scores"`    // The hand score of each player.
+	Payments  [score.NumPlayers]int `json:"payments"`  // What each player gains (positive) or pays (negative).
+}
+
+// Game is a single hand of play at a table of four.
+type Game struct {
+	rules     *score.RuleSet
+	wall      *wall.Wall
+	players   [score.NumPlayers]*Player
+	dealer    int
+	roundWind score.Tile
+
+	phase Phase
+	turn  int
+
+	// The last tile drawn by the player whose turn it is, and whether it was a replacement.
+	drawn       score.Tile
+	replacement bool
+
+	// The discard that can be claimed, or the tile added to a pung that can be robbed.
+	claimTile score.Tile
+	discarder int
+	robbing   bool
+	responses map[int]Action
+
+	// Whether the dealer didn't get a second turn yet, and nothing was claimed or
+	// declared; needed to win 'out in the draw'.
+	firstGoAround bool
+
+	events []Event
+	result *Result
+
+	bots [score.NumPlayers]Bot
+}
+
+// next returns the seat after the given one, in turn order.
+func next(seat int) int {
+	return (seat + 1) % score.NumPlayers
+}
+
+// SeatWind returns the wind of the seat, given the seat of the dealer, who is East.
+func SeatWind(seat, dealer int) score.Tile {
+	return score.WindEast + score.Tile((seat-dealer+score.NumPlayers)%score.NumPlayers)
+}
+
+// New deals the tiles from the wall, and returns a game waiting for the dealer to discard.
+func New(rules *score.RuleSet, w *wall.Wall, dealer int, roundWind score.Tile) *Game {
+	g := &Game{
+		rules:         rules,
+		wall:          w,
+		dealer:        dealer,
+		roundWind:     roundWind,
+		turn:          dealer,
+		discarder:     -1,
+		firstGoAround: true,
+	}
+
+	for offset := 0; offset < score.NumPlayers; offset++ {
+		seat := (dealer + offset) % score.NumPlayers
+		player := &Player{
+			Wind:      SeatWind(seat, dealer),
+			Concealed: []score.Tile{},
+			Melds:     []score.Set{},
+			Bonus:     []score.Tile{},
+			Discards:  []score.Tile{},
+		}
+		g.players[seat] = player
+
+		// The dealer gets the 14th tile, and thereby the first turn.
+		amount := 13
+		if seat == dealer {
+			amount = 14
+		}
+		for idx := 0; idx < amount; idx++ {
+			tile, _ := w.Draw()
+			player.add(tile)
+		}
+		g.emit(Event{Type: EventDeal, Seat: seat, Tiles: append([]score.Tile{}, player.Concealed...), Private: true})
+	}
+
+	for offset := 0; offset < score.NumPlayers; offset++ {
+		if !g.replaceBonusTiles((dealer + offset) % score.NumPlayers) {
+			return g
+		}
+	}
+
+	// Replacing flowers and seasons hands out turns, so give it back to the dealer.
+	g.turn = dealer
+	g.drawn = score.NoTile
+	g.replacement = false
+	return g
+}
+
+// Rules returns the rule set the hand is scored with.
+func (g *Game) Rules() *score.RuleSet { return g.rules }
+
+// Dealer returns the seat of the dealer, who is East.
+func (g *Game) Dealer() int { return g.dealer }
+
+// RoundWind returns the wind of the round.
+func (g *Game) RoundWind() score.Tile { return g.roundWind }
+
+// Phase returns what the game is waiting for.
+func (g *Game) Phase() Phase { return g.phase }
+
+// Turn returns the seat whose turn it is.
+func (g *Game) Turn() int { return g.turn }
+
+// Player returns the state of the seat. It must not be modified.
+func (g *Game) Player(seat int) *Player { return g.players[seat] }
+
+// WallRemaining returns the number of tiles that can still be drawn.
+func (g *Game) WallRemaining() int { return g.wall.Remaining() }
+
+// ClaimTile returns the tile that can be claimed, and who discarded it,
+// or NoTile and -1 when nothing can be claimed.
+func (g *Game) ClaimTile() (score.Tile, int) {
+	if g.phase != PhaseClaim {
+		return score.NoTile, -1
+	}
+	return g.claimTile, g.discarder
+}
+
+// Finished returns true when the hand is over.
+func (g *Game) Finished() bool { return g.phase == PhaseFinished }
+
+// Result returns the outcome of the hand, or nil while it is still being played.
+func (g *Game) Result() *Result { return g.result }
+
+// Events returns everything that happened since the given number of events.
+// Pass the total number of events seen so far to get only the new ones.
+func (g *Game) Events(from int) []Event {
+	if from >= len(g.events) {
+		return []Event{}
+	}
+	return append([]Event{}, g.events[from:]...)
+}
+
+// VisibleTiles returns all tiles that the seat can see outside its own hand:
+// every discard, and the melds of the other players.
+func (g *Game) VisibleTiles(seat int) []score.Tile {
+	visible := []score.Tile{}
+	for other, player := range g.players {
+		if other == seat {
+			visible = append(visible, player.Discards...)
+			continue
+		}
+		visible = append(visible, player.visibleTiles()...)
+	}
+	return visible
+}
+
+func (g *Game) emit(event Event) {
+	g.events = append(g.events, event)
+}
+
+// Apply performs the action, and advances the game as far as it can without
+// needing further actions from players that aren't bots.
+func (g *Game) Apply(action Action) error {
+	if g.phase == PhaseFinished {
+		return ErrGameFinished
+	}
+
+	if !containsAction(g.ValidActions(action.Seat), action) {
+		return fmt.Errorf("%s: %s", ErrInvalidAction, action)
+	}
+
+	g.perform(action)
+	g.playBots()
+	return nil
+}
+
+// perform carries out a valid action.
+func (g *Game) perform(action Action) {
+	if g.phase == PhaseClaim {
+		g.responses[action.Seat] = action
+		g.resolveClaims()
+		return
+	}
+
+	switch action.Type {
+	case ActionDiscard:
+		g.discard(action.Seat, action.Tile)
+	case ActionKong:
+		g.declareKong(action.Seat, action.Tile)
+	case ActionWin:
+		g.win(action.Seat, -1, g.drawn, false)
+	}
+}
+
+// startTurn lets the seat draw a tile from the wall, or ends the hand when the wall is empty.
+func (g *Game) startTurn(seat int) {
+	if seat == g.dealer {
+		// Everybody had a turn, so the first go-around is over.
+		g.firstGoAround = false
+	}
+	g.phase = PhaseDiscard
+	g.turn = seat
+	tile, err := g.wall.Draw()
+	if err != nil {
+		g.exhausted()
+		return
+	}
+
+	g.players[seat].add(tile)
+	g.drawn = tile
+	g.replacement = false
+	g.emit(Event{Type: EventDraw, Seat: seat, Tiles: []score.Tile{tile}, Private: true})
+	g.replaceBonusTiles(seat)
+}
+
+// drawReplacement gives the seat a tile from the dead wall, after a kong.
+// It returns false when the hand ended because the wall ran out.
+func (g *Game) drawReplacement(seat int) bool {
+	g.phase = PhaseDiscard
+	g.turn = seat
+	tile, err := g.wall.DrawReplacement()
+	if err != nil {
+		g.exhausted()
+		return false
+	}
+
+	g.players[seat].add(tile)
+	g.drawn = tile
+	g.replacement = true
+	g.emit(Event{Type: EventDraw, Seat: seat, Tiles: []score.Tile{tile}, Replacement: true, Private: true})
+	return true
+}
+
+// replaceBonusTiles exposes the flowers and seasons of the seat, and replaces them.
+// It returns false when the hand ended because the wall ran out.
+func (g *Game) replaceBonusTiles(seat int) bool {
+	player := g.players[seat]
+	for {
+		bonus := score.NoTile
+		for _, tile := range player.Concealed {
+			if tile.IsBonus() {
+				bonus = tile
+				break
+			}
+		}
+		if bonus == score.NoTile {
+			return true
+		}
+
+		player.remove(bonus)
+		player.Bonus = append(player.Bonus, bonus)
+		g.emit(Event{Type: EventBonus, Seat: seat, Tiles: []score.Tile{bonus}})
+		if !g.drawReplacement(seat) {
+			return false
+		}
+	}
+}
+
+func (g *Game) discard(seat int, tile score.Tile) {
+	player := g.players[seat]
+	player.remove(tile)
+	player.Discards = append(player.Discards, tile)
+	g.emit(Event{Type: EventDiscard, Seat: seat, Tiles: []score.Tile{tile}})
+	g.openClaims(seat, tile, false)
+}
+
+// openClaims gives the other players the chance to claim the tile. Players who
+// can't do anything with it pass automatically.
+func (g *Game) openClaims(discarder int, tile score.Tile, robbing bool) {
+	g.phase = PhaseClaim
+	g.claimTile = tile
+	g.discarder = discarder
+	g.robbing = robbing
+	g.responses = map[int]Action{}
+
+	for seat := range g.players {
+		actions := g.ValidActions(seat)
+		if len(actions) == 1 && actions[0].Type == ActionPass {
+			g.responses[seat] = actions[0]
+		}
+	}
+	g.resolveClaims()
+}
+
+// resolveClaims gives the tile to the highest claim once everybody responded.
+// Wins go before pungs and kongs, which go before chows. When several players
+// want to win, the first one in turn order after the discarder gets the tile.
+func (g *Game) resolveClaims() {
+	if len(g.responses) < score.NumPlayers-1 {
+		return
+	}
+
+	best := Action{Seat: -1}
+	for offset := 1; offset < score.NumPlayers; offset++ {
+		response := g.responses[(g.discarder+offset)%score.NumPlayers]
+		if best.Seat == -1 || response.Type.priority() > best.Type.priority() {
+			best = response
+		}
+	}
+
+	tile, discarder, robbing := g.claimTile, g.discarder, g.robbing
+	g.responses = nil
+	g.discarder = -1
+	g.robbing = false
+
+	if best.Type == ActionPass {
+		if robbing {
+			g.completeKong(discarder, tile)
+		} else {
+			g.startTurn(next(discarder))
+		}
+		return
+	}
+
+	if best.Type == ActionWin {
+		g.players[best.Seat].add(tile)
+		if !robbing {
+			g.takeDiscard(discarder)
+		}
+		g.win(best.Seat, discarder, tile, robbing)
+		return
+	}
+
+	g.firstGoAround = false
+	g.takeDiscard(discarder)
+	player := g.players[best.Seat]
+	var set []score.Tile
+	switch best.Type {
+	case ActionChow:
+		set = []score.Tile{best.Tile, best.Tile + 1, best.Tile + 2}
+	case ActionPung:
+		set = []score.Tile{tile, tile, tile}
+	case ActionKong:
+		set = []score.Tile{tile, tile, tile, tile}
+	}
+	player.add(tile)
+	player.remove(set...)
+	player.Melds = append(player.Melds, score.Set{Tiles: set})
+	g.emit(Event{Type: EventClaim, Seat: best.Seat, Tiles: set})
+
+	if best.Type == ActionKong {
+		g.drawReplacement(best.Seat)
+		return
+	}
+	g.phase = PhaseDiscard
+	g.turn = best.Seat
+	g.drawn = score.NoTile
+	g.replacement = false
+}
+
+// takeDiscard removes the claimed tile from the discarder's discards.
+func (g *Game) takeDiscard(discarder int) {
+	player := g.players[discarder]
+	player.Discards = player.Discards[:len(player.Discards)-1]
+}
+
+// declareKong declares a concealed kong, or adds the tile to a melded pung.
+// The latter can be robbed by another player who wins on the tile.
+func (g *Game) declareKong(seat int, tile score.Tile) {
+	g.firstGoAround = false
+	player := g.players[seat]
+	player.remove(tile)
+	if player.meldedPung(tile) >= 0 {
+		g.openClaims(seat, tile, true)
+		return
+	}
+
+	player.remove(tile, tile, tile)
+	set := []score.Tile{tile, tile, tile, tile}
+	player.Melds = append(player.Melds, score.Set{Tiles: set, Concealed: true})
+	g.emit(Event{Type: EventKong, Seat: seat, Tiles: set})
+	g.drawReplacement(seat)
+}
+
+// completeKong adds the tile to the melded pung, after nobody robbed it.
+func (g *Game) completeKong(seat int, tile score.Tile) {
+	player := g.players[seat]
+	meld := &player.Melds[player.meldedPung(tile)]
+	meld.Tiles = append(meld.Tiles, tile)
+	g.emit(Event{Type: EventKong, Seat: seat, Tiles: meld.Tiles})
+	g.drawReplacement(seat)
+}
+
+// win scores the winning hand and settles the payments. The winning tile is already
+// part of the winner's concealed tiles. Robbed tells whether it was taken from a kong.
+func (g *Game) win(seat, discarder int, winningTile score.Tile, robbed bool) {
+	player := g.players[seat]
+	selfDrawn := discarder == -1
+	conditions := score.Hand{
+		Bonus:                player.Bonus,
+		WindOwn:              player.Wind,
+		WindRound:            g.roundWind,
+		WinSelfDrawn:         selfDrawn,
+		WinOnReplacementTile: selfDrawn && g.replacement,
+		LastTileOfWall:       selfDrawn && !g.replacement && g.wall.IsEmpty(),
+		RobbedTheKong:        !selfDrawn && robbed,
+		OutInDraw:            g.firstGoAround,
+		WinOnDeal:            g.firstGoAround && len(player.Discards) == 0,
+		LastChance:           g.isLastChance(player, winningTile),
+	}
+
+	interpretations, err := g.rules.Interpret(player.Concealed, player.Melds, conditions)
+	if err != nil {
+		// Winning is only allowed with a winning hand, so this cannot happen.
+		panic(fmt.Sprintf("seat %d won with %s: %s", seat, score.FormatTiles(player.Concealed), err))
+	}
+	hand := interpretations[0].Hand
+	breakdown := g.rules.ScoreBreakdown(&hand)
+
+	result := Result{
+		Winner:    seat,
+		Discarder: discarder,
+		Hand:      &hand,
+		Breakdown: &breakdown,
+	}
+	for other, otherPlayer := range g.players {
+		if other == seat {
+			result.Scores[other] = breakdown.Score
+			continue
+		}
+		losingHand := otherPlayer.losingHand(g.roundWind)
+		result.Scores[other] = g.rules.Score(&losingHand)
+	}
+	result.Payments, err = g.rules.Settle(score.Settlement{
+		Scores:    result.Scores,
+		Winner:    seat,
+		Discarder: discarder,
+		East:      g.dealer,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	g.emit(Event{Type: EventWin, Seat: seat, Tiles: append([]score.Tile{}, player.Concealed...)})
+	g.finish(&result)
+}
+
+// isLastChance returns true when the winning tile was the only tile the hand waited on.
+func (g *Game) isLastChance(player *Player, winningTile score.Tile) bool {
+	before := append([]score.Tile{}, player.Concealed...)
+	for idx, tile := range before {
+		if tile == winningTile {
+			before = append(before[:idx], before[idx+1:]...)
+			break
+		}
+	}
+	hand := score.Hand{Sets: append(append([]score.Set{}, player.Melds...), score.Set{Tiles: before, Concealed: true})}
+	return len(g.rules.Waits(&hand)) == 1
+}
+
+// exhausted ends the hand without a winner.
+func (g *Game) exhausted() {
+	g.emit(Event{Type: EventExhausted, Seat: -1})
+	g.finish(&Result{Winner: -1, Discarder: -1})
+}
+
+func (g *Game) finish(result *Result) {
+	g.result = result
+	g.phase = PhaseFinished
+	g.responses = nil
+	g.claimTile = score.NoTile
+}
@@ -0,0 +1,352 @@
+package game
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/wall"
+	check "gopkg.in/check.v1"
+)
+
+type GameTestSuite struct{}
+
+var _ = check.Suite(&GameTestSuite{})
+
+func mustParseTiles(c *check.C, notation string) []score.Tile {
+	tiles, err := score.ParseTiles(notation)
+	if err != nil {
+		c.Fatal(err)
+	}
+	return tiles
+}
+
+// stackedWall returns a wall that deals the given tiles to the seats, starting with the
+// dealer, followed by the given draws. The rest of the wall is filled up with the
+// remaining tiles, except the flowers and seasons.
+func stackedWall(c *check.C, hands [score.NumPlayers]string, draws string) *wall.Wall {
+	tiles := []score.Tile{}
+	for _, hand := range hands {
+		tiles = append(tiles, mustParseTiles(c, hand)...)
+	}
+	tiles = append(tiles, mustParseTiles(c, draws)...)
+
+	used := map[score.Tile]int{}
+	for _, tile := range tiles {
+		used[tile]++
+	}
+	available := map[score.Tile]int{}
+	for _, tile := range wall.AllTiles() {
+		available[tile]++
+	}
+	for tile, count := range used {
+		if count > available[tile] {
+			c.Fatalf("%d times tile %d in the wall", count, tile)
+		}
+	}
+
+	for _, tile := range wall.AllTiles() {
+		if tile.IsBonus() {
+			continue
+		}
+		if used[tile] > 0 {
+			used[tile]--
+			continue
+		}
+		tiles = append(tiles, tile)
+	}
+	return wall.FromTiles(tiles)
+}
+
+// stackedSize is the number of tiles in a stacked wall without flowers and seasons.
+const stackedSize = wall.Size - 8
+
+func mustApply(c *check.C, g *Game, action Action) {
+	if err := g.Apply(action); err != nil {
+		c.Fatal(err)
+	}
+}
+
+// countTiles returns the number of tiles at the table, including the wall.
+func countTiles(g *Game) int {
+	total := g.wall.Remaining() + g.wall.DeadRemaining()
+	for _, player := range g.players {
+		total += len(player.Concealed) + len(player.Bonus) + len(player.Discards)
+		for _, meld := range player.Melds {
+			total += len(meld.Tiles)
+		}
+	}
+	return total
+}
+
+func (s *GameTestSuite) TestDeal(c *check.C) {
+	g := New(score.DefaultRuleSet(), wall.New(47), 2, score.WindSouth)
+	assert.Equal(c, PhaseDiscard, g.Phase())
+	assert.Equal(c, 2, g.Turn())
+	assert.Equal(c, wall.Size, countTiles(g))
+
+	for seat := 0; seat < score.NumPlayers; seat++ {
+		player := g.Player(seat)
+		expected := 13
+		if seat == 2 {
+			expected = 14
+		}
+		assert.Len(c, player.Concealed, expected)
+		for _, tile := range player.Concealed {
+			assert.False(c, tile.IsBonus())
+		}
+	}
+	assert.Equal(c, score.WindEast, g.Player(2).Wind)
+	assert.Equal(c, score.WindSouth, g.Player(3).Wind)
+	assert.Equal(c, score.WindNorth, g.Player(1).Wind)
+
+	// Only the dealer's own tiles are visible to them.
+	for _, event := range g.Events(0) {
+		if event.Type == EventDeal && event.Seat != 2 {
+			assert.Nil(c, event.For(2).Tiles)
+		}
+		assert.NotNil(c, event.For(event.Seat).Tiles)
+	}
+}
+
+// playOut plays the game until it ends, discarding whatever is drawn and never claiming anything.
+func playOut(c *check.C, g *Game) {
+	for turns := 0; !g.Finished(); turns++ {
+		if turns > wall.Size {
+			c.Fatal("game doesn't end")
+		}
+
+		for seat := 0; seat < score.NumPlayers; seat++ {
+			if action, ok := g.DefaultAction(seat); ok {
+				mustApply(c, g, action)
+			}
+		}
+		assert.Equal(c, wall.Size, countTiles(g))
+	}
+}
+
+func (s *GameTestSuite) TestPlayUntilExhausted(c *check.C) {
+	g := New(score.DefaultRuleSet(), wall.New(1), 0, score.WindEast)
+	playOut(c, g)
+
+	result := g.Result()
+	assert.Equal(c, -1, result.Winner)
+	assert.Equal(c, [score.NumPlayers]int{}, result.Payments)
+	events := g.Events(0)
+	assert.Equal(c, EventExhausted, events[len(events)-1].Type)
+	assert.Equal(c, ErrGameFinished, g.Apply(Action{Seat: 0, Type: ActionPass}))
+}
+
+func (s *GameTestSuite) TestHeavenlyHand(c *check.C) {
+	w := stackedWall(c, [score.NumPlayers]string{
+		"123b 456b 789b 123c 11s",
+		"2345678c 234567s",
+		"1b 2b 3b 4b 5b 6b 7b 8b 9b 1c 2c 3c 4c",
+		"EEE SSS WWW NNN r",
+	}, "")
+	g := New(score.DefaultRuleSet(), w, 0, score.WindEast)
+	actions := g.ValidActions(0)
+	assert.Equal(c, Action{0, ActionWin, score.NoTile}, actions[0])
+	mustApply(c, g, actions[0])
+
+	result := g.Result()
+	assert.True(c, g.Finished())
+	assert.Equal(c, 0, result.Winner)
+	assert.Equal(c, -1, result.Discarder)
+	assert.Contains(c, result.Breakdown.LimitHands, "heavenly hand")
+	assert.Equal(c, score.DefaultRuleSet().Limit, result.Scores[0])
+	assert.True(c, result.Payments[0] > 0)
+}
+
+func (s *GameTestSuite) TestOutInDrawEnds(c *check.C) {
+	hands := [score.NumPlayers]string{
+		"147b 258c 369s ESWNr",
+		"147c 258s 369b ESWg",
+		"123b 456b 789b 123c 9s",
+		"258b 147s 369c NN gw",
+	}
+	// Winning on a discard in the first go-around is out in the draw.
+	g := New(score.DefaultRuleSet(), stackedWall(c, hands, "9s"), 0, score.WindEast)
+	mustApply(c, g, Action{0, ActionDiscard, score.WindEast})
+	mustApply(c, g, Action{1, ActionDiscard, score.Bamboo9})
+	mustApply(c, g, Action{2, ActionWin, score.NoTile})
+	assert.True(c, g.Result().Hand.OutInDraw)
+
+	g = New(score.DefaultRuleSet(), stackedWall(c, hands, "w r S W 9s"), 0, score.WindEast)
+	mustApply(c, g, Action{0, ActionDiscard, score.WindEast})
+	mustApply(c, g, Action{1, ActionDiscard, score.DragonWhite})
+	mustApply(c, g, Action{2, ActionDiscard, score.DragonRed})
+	mustApply(c, g, Action{3, ActionDiscard, score.WindSouth})
+
+	// The dealer's second turn ends the first go-around, even though nothing was claimed.
+	mustApply(c, g, Action{0, ActionDiscard, score.WindWest})
+	mustApply(c, g, Action{1, ActionDiscard, score.Bamboo9})
+	mustApply(c, g, Action{2, ActionWin, score.NoTile})
+
+	result := g.Result()
+	assert.Equal(c, 2, result.Winner)
+	assert.Equal(c, 1, result.Discarder)
+	assert.False(c, result.Hand.OutInDraw)
+	assert.False(c, result.Hand.WinOnDeal)
+}
+
+func (s *GameTestSuite) TestClaimPriority(c *check.C) {
+	hands := [score.NumPlayers]string{
+		"123b 456b 789b 123c 5s E",
+		"46s 11c 99c rr 2468b w",
+		"55s 33c 77c gg 1357b S",
+		"EEE SSS WWW NNN 5s",
+	}
+
+	// Seat 3 waits on the 5s, so wins before seat 2 can pung it or seat 1 can chow it.
+	g := New(score.DefaultRuleSet(), stackedWall(c, hands, ""), 0, score.WindEast)
+	mustApply(c, g, Action{0, ActionDiscard, score.Bamboo5})
+	assert.Equal(c, PhaseClaim, g.Phase())
+	tile, discarder := g.ClaimTile()
+	assert.Equal(c, score.Bamboo5, tile)
+	assert.Equal(c, 0, discarder)
+
+	assert.Contains(c, g.ValidActions(1), Action{1, ActionChow, score.Bamboo4})
+	assert.Contains(c, g.ValidActions(2), Action{2, ActionPung, score.NoTile})
+	assert.Contains(c, g.ValidActions(3), Action{3, ActionWin, score.NoTile})
+	mustApply(c, g, Action{1, ActionChow, score.Bamboo4})
+	mustApply(c, g, Action{2, ActionPung, score.NoTile})
+	assert.Equal(c, PhaseClaim, g.Phase(), "seat 3 still has to respond")
+
+	// Claiming twice is not allowed.
+	assert.NotNil(c, g.Apply(Action{2, ActionPass, score.NoTile}))
+
+	mustApply(c, g, Action{3, ActionWin, score.NoTile})
+	assert.True(c, g.Finished())
+	assert.Equal(c, 3, g.Result().Winner)
+	assert.Equal(c, 0, g.Result().Discarder)
+	assert.Empty(c, g.Player(0).Discards)
+	assert.True(c, g.Result().Payments[0] < 0)
+
+	// Without the win, the pung goes before the chow.
+	g = New(score.DefaultRuleSet(), stackedWall(c, hands, ""), 0, score.WindEast)
+	mustApply(c, g, Action{0, ActionDiscard, score.Bamboo5})
+	mustApply(c, g, Action{1, ActionChow, score.Bamboo4})
+	mustApply(c, g, Action{2, ActionPung, score.NoTile})
+	mustApply(c, g, Action{3, ActionPass, score.NoTile})
+	assert.Equal(c, PhaseDiscard, g.Phase())
+	assert.Equal(c, 2, g.Turn())
+	assert.Equal(c, []score.Set{{Tiles: []score.Tile{score.Bamboo5, score.Bamboo5, score.Bamboo5}}}, g.Player(2).Melds)
+	assert.Len(c, g.Player(2).Concealed, 11)
+	assert.Equal(c, stackedSize, countTiles(g))
+
+	// Seat 3 is skipped, and has to wait for the discard of seat 2.
+	mustApply(c, g, Action{2, ActionDiscard, score.Balls1})
+	assert.Equal(c, PhaseDiscard, g.Phase(), "nobody can use the 1b")
+	assert.Equal(c, 3, g.Turn())
+}
+
+func (s *GameTestSuite) TestChow(c *check.C) {
+	hands := [score.NumPlayers]string{
+		"123b 456b 789b 123c 5s E",
+		"46s 11c 99c rr 2468b w",
+		"147b 258c 369s ESW N",
+		"147c 258s 369b ESW N",
+	}
+	g := New(score.DefaultRuleSet(), stackedWall(c, hands, ""), 0, score.WindEast)
+	mustApply(c, g, Action{0, ActionDiscard, score.Bamboo5})
+
+	// Only the next player can chow.
+	assert.Empty(c, g.ValidActions(2))
+	assert.Empty(c, g.ValidActions(3))
+	mustApply(c, g, Action{1, ActionChow, score.Bamboo4})
+	assert.Equal(c, 1, g.Turn())
+	assert.Equal(c, []score.Set{{Tiles: []score.Tile{score.Bamboo4, score.Bamboo5, score.Bamboo6}}}, g.Player(1).Melds)
+	assert.Equal(c, EventClaim, g.Events(0)[len(g.Events(0))-1].Type)
+}
+
+func (s *GameTestSuite) TestFlowerReplacement(c *check.C) {
+	w := stackedWall(c, [score.NumPlayers]string{
+		"123b 456b 789b 123c 5s 1f",
+		"147b 258c 369s ESW N",
+		"147c 258s 369b ESW N",
+		"rrr ggg www 11s 99s",
+	}, "")
+	g := New(score.DefaultRuleSet(), w, 0, score.WindEast)
+	assert.Equal(c, []score.Tile{score.Flower1}, g.Player(0).Bonus)
+	assert.Len(c, g.Player(0).Concealed, 14)
+	assert.Equal(c, 0, g.Turn())
+	assert.Equal(c, wall.DeadWallSize, g.wall.DeadRemaining())
+	assert.Equal(c, stackedSize+1, countTiles(g))
+}
+
+func (s *GameTestSuite) TestConcealedKong(c *check.C) {
+	w := stackedWall(c, [score.NumPlayers]string{
+		"1111b 456b 789b 123c 5s",
+		"258b 258c 369s ESW N",
+		"147c 258s 369b ESW N",
+		"rrr ggg www 11s 99s",
+	}, "")
+	g := New(score.DefaultRuleSet(), w, 0, score.WindEast)
+	remaining := g.WallRemaining()
+	mustApply(c, g, Action{0, ActionKong, score.Balls1})
+	assert.Equal(c, []score.Set{{Tiles: []score.Tile{score.Balls1, score.Balls1, score.Balls1, score.Balls1}, Concealed: true}},
+		g.Player(0).Melds)
+	assert.Len(c, g.Player(0).Concealed, 11)
+	assert.Equal(c, remaining-1, g.WallRemaining(), "the dead wall is replenished")
+	assert.Equal(c, 0, g.Turn())
+	assert.True(c, g.replacement)
+}
+
+func (s *GameTestSuite) TestRobbingTheKong(c *check.C) {
+	hands := [score.NumPlayers]string{
+		"55b 456b 789b 123c 5s NW",
+		"147b 258c 1369s rgw",
+		"123b 345c 678c 99s 46b",
+		"EEE SSS WWW NNN r",
+	}
+	g := New(score.DefaultRuleSet(), stackedWall(c, hands, "5b"), 0, score.WindEast)
+	mustApply(c, g, Action{0, ActionDiscard, score.Bamboo5})
+	// Seat 1 draws the last 5b, and discards it.
+	mustApply(c, g, Action{1, ActionDiscard, score.Balls5})
+	// Seat 2 could win on the 5b, but lets it go by.
+	assert.Contains(c, g.ValidActions(2), Action{2, ActionWin, score.NoTile})
+	mustApply(c, g, Action{0, ActionPung, score.NoTile})
+	mustApply(c, g, Action{2, ActionPass, score.NoTile})
+
+	// Seat 0 adds its own 5b to the pung, and seat 2 robs it.
+	mustApply(c, g, Action{0, ActionKong, score.Balls5})
+	assert.Equal(c, PhaseClaim, g.Phase())
+	assert.Equal(c, []Action{{2, ActionPass, score.NoTile}, {2, ActionWin, score.NoTile}}, g.ValidActions(2))
+	mustApply(c, g, Action{2, ActionWin, score.NoTile})
+
+	result := g.Result()
+	assert.Equal(c, 2, result.Winner)
+	assert.Equal(c, 0, result.Discarder)
+	assert.True(c, result.Hand.RobbedTheKong)
+	assert.Len(c, g.Player(0).Melds[0].Tiles, 3)
+	assert.Equal(c, stackedSize, countTiles(g))
+}
+
+func (s *GameTestSuite) TestKongNotRobbed(c *check.C) {
+	hands := [score.NumPlayers]string{
+		"555b 789b 123c 789c N 5s",
+		"147b 258c 1369s 147c",
+		"123b 345c 678c 99s 46b",
+		"147b 258s 369c 2468s",
+	}
+	// Without other winds and dragons in the hands, the first replacement tile is a north.
+	g := New(score.DefaultRuleSet(), stackedWall(c, hands, "5b"), 0, score.WindEast)
+	mustApply(c, g, Action{0, ActionDiscard, score.Bamboo5})
+	mustApply(c, g, Action{1, ActionDiscard, score.Balls5})
+	mustApply(c, g, Action{0, ActionPung, score.NoTile})
+	mustApply(c, g, Action{2, ActionPass, score.NoTile})
+
+	// Seat 0 adds its own 5b to the pung, nobody robs it, and seat 0 wins on the replacement tile.
+	mustApply(c, g, Action{0, ActionKong, score.Balls5})
+	mustApply(c, g, Action{2, ActionPass, score.NoTile})
+	assert.Equal(c, score.WindNorth, g.drawn)
+	mustApply(c, g, Action{0, ActionWin, score.NoTile})
+
+	result := g.Result()
+	assert.Equal(c, 0, result.Winner)
+	assert.Equal(c, -1, result.Discarder)
+	assert.True(c, result.Hand.WinOnReplacementTile)
+	assert.False(c, result.Hand.RobbedTheKong)
+	for _, detector := range result.Breakdown.Detectors {
+		assert.NotEqual(c, "robbed the kong", detector.Name)
+	}
+}
@@ -0,0 +1,211 @@
+/*
+ * A match of several hands, played through the four rounds of the winds.
+ *
+ * Every player deals in turn. Once the deal has gone around the table, the
+ * next round starts with the next round wind; the match ends after the north
+ * round. Whether the dealer stays East after winning or after a hand without
+ * winner is up to the rule set.
+ */
+
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/wall"
+)
+
+// Standard errors.
+var (
+	ErrMatchFinished   = errors.New("match is finished")
+	ErrHandNotFinished = errors.New("hand is still being played")
+)
+
+// HandRecord is the history of one hand of a match.
+type HandRecord struct {
+	Number    int        `json:"number"`
+	RoundWind score.Tile `json:"round_wind"`
+	Dealer    int        `json:"dealer"`
+	Seed      int64      `json:"seed"` // The seed of the wall, to replay the hand.
+	Result    Result     `json:"result"`
+}
+
+// Match is a series of hands between the same four players.
+// Matches can be saved as JSON, except for the hand that is being played.
+type Match struct {
+	RuleSet   string                   `json:"ruleset"`
+	Players   [score.NumPlayers]string `json:"players"`
+	Seed      int64                    `json:"seed"`
+	RoundWind score.Tile               `json:"round_wind"`
+	Dealer    int                      `json:"dealer"`
+	Scores    [score.NumPlayers]int    `json:"scores"` // Running total of the payments.
+	History   []HandRecord             `json:"history"`
+	Finished  bool                     `json:"finished"`
+
+	rules *score.RuleSet
+	game  *Game
+	bots  [score.NumPlayers]Bot
+}
+
+// NewMatch starts a match between the players. Seat 0 deals the first hand. The walls
+// of all hands follow from the seed, so that the match can be replayed.
+func NewMatch(rules *score.RuleSet, players [score.NumPlayers]string, seed int64) *Match {
+	return &Match{
+		RuleSet:   rules.Name,
+		Players:   players,
+		Seed:      seed,
+		RoundWind: score.WindEast,
+		History:   []HandRecord{},
+		rules:     rules,
+	}
+}
+
+// LoadMatch reads a match saved with Save(). Its rule set must be registered.
+func LoadMatch(r io.Reader) (*Match, error) {
+	m := Match{}
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+
+	rules, err := score.LookupRuleSet(m.RuleSet)
+	if err != nil {
+		return nil, err
+	}
+	m.rules = rules
+	return &m, nil
+}
+
+// LoadMatchFile reads a match from a file written by SaveFile().
+func LoadMatchFile(filename string) (*Match, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoadMatch(file)
+}
+
+// Save writes the match as JSON.
+func (m *Match) Save(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(m)
+}
+
+// SaveFile writes the match as JSON to the file.
+func (m *Match) SaveFile(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := m.Save(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Rules returns the rule set the match is played with.
+func (m *Match) Rules() *score.RuleSet {
+	return m.rules
+}
+
+// Game returns the hand that is being played, or nil between hands.
+func (m *Match) Game() *Game {
+	return m.game
+}
+
+// handSeed returns the seed of the wall of the next hand.
+func (m *Match) handSeed() int64 {
+	return m.Seed + int64(len(m.History))
+}
+
+// StartHand deals the next hand.
+func (m *Match) StartHand() (*Game, error) {
+	if m.Finished {
+		return nil, ErrMatchFinished
+	}
+	if m.game != nil {
+		return nil, ErrHandNotFinished
+	}
+
+	m.game = New(m.rules, wall.New(m.handSeed()), m.Dealer, m.RoundWind)
+	for seat, bot := range m.bots {
+		if bot != nil {
+			m.game.SetBot(seat, bot)
+		}
+	}
+	return m.game, nil
+}
+
+// SetBot lets the bot play the seat, from the next hand on. Bots are not saved
+// with the match.
+func (m *Match) SetBot(seat int, bot Bot) {
+	m.bots[seat] = bot
+}
+
+// FinishHand records the result of the hand that was played, and determines
+// the dealer and round wind of the next hand.
+func (m *Match) FinishHand() (*HandRecord, error) {
+	if m.game == nil || !m.game.Finished() {
+		return nil, ErrHandNotFinished
+	}
+
+	record := HandRecord{
+		Number:    len(m.History) + 1,
+		RoundWind: m.RoundWind,
+		Dealer:    m.Dealer,
+		Seed:      m.handSeed(),
+		Result:    *m.game.Result(),
+	}
+	m.History = append(m.History, record)
+	m.game = nil
+	m.advance(&record.Result)
+	return &m.History[len(m.History)-1], nil
+}
+
+// advance adds the payments to the running scores, and passes on the deal if needed.
+func (m *Match) advance(result *Result) {
+	for seat, payment := range result.Payments {
+		m.Scores[seat] += payment
+	}
+
+	switch {
+	case result.Winner == m.Dealer && m.rules.Dealer.KeepsOnWin:
+		return
+	case result.Winner == -1 && m.rules.Dealer.KeepsOnDraw:
+		return
+	}
+
+	m.Dealer = next(m.Dealer)
+	if m.Dealer != 0 {
+		return
+	}
+	// The deal went around the table, so the round is over.
+	if m.RoundWind == score.WindNorth {
+		m.Finished = true
+		return
+	}
+	m.RoundWind++
+}
+
+// Standings returns the seats ordered by their running score, highest first.
+func (m *Match) Standings() []int {
+	seats := []int{0, 1, 2, 3}
+	for i := 1; i < len(seats); i++ {
+		for j := i; j > 0 && m.Scores[seats[j]] > m.Scores[seats[j-1]]; j-- {
+			seats[j], seats[j-1] = seats[j-1], seats[j]
+		}
+	}
+	return seats
+}
+
+func (m *Match) String() string {
+	return fmt.Sprintf("match %v, %s round, hand %d", m.Players,
+		score.FormatTiles([]score.Tile{m.RoundWind}), len(m.History)+1)
+}
@@ -0,0 +1,116 @@
+package game
+
+import (
+	"bytes"
+
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+type MatchTestSuite struct{}
+
+var _ = check.Suite(&MatchTestSuite{})
+
+var matchPlayers = [score.NumPlayers]string{"Anna", "Bart", "Cleo", "Dirk"}
+
+func (s *MatchTestSuite) TestDealerSuccession(c *check.C) {
+	rules := score.DefaultRuleSet().Copy("keeps-on-win")
+	rules.Dealer = score.DealerRules{KeepsOnWin: true, KeepsOnDraw: false}
+	m := NewMatch(rules, matchPlayers, 1)
+
+	// The dealer keeps the deal after winning.
+	m.advance(&Result{Winner: 0, Payments: [score.NumPlayers]int{30, -10, -10, -10}})
+	assert.Equal(c, 0, m.Dealer)
+	assert.Equal(c, [score.NumPlayers]int{30, -10, -10, -10}, m.Scores)
+
+	// Someone else winning or a draw passes it on.
+	m.advance(&Result{Winner: 2, Payments: [score.NumPlayers]int{-8, -8, 24, -8}})
+	assert.Equal(c, 1, m.Dealer)
+	m.advance(&Result{Winner: -1})
+	assert.Equal(c, 2, m.Dealer)
+	assert.Equal(c, score.WindEast, m.RoundWind)
+	assert.Equal(c, [score.NumPlayers]int{22, -18, 14, -18}, m.Scores)
+
+	// Going around the table starts the next round.
+	m.advance(&Result{Winner: -1})
+	m.advance(&Result{Winner: -1})
+	assert.Equal(c, 0, m.Dealer)
+	assert.Equal(c, score.WindSouth, m.RoundWind)
+	assert.False(c, m.Finished)
+
+	m.RoundWind = score.WindNorth
+	m.Dealer = 3
+	m.advance(&Result{Winner: -1})
+	assert.True(c, m.Finished)
+	assert.Equal(c, []int{0, 2, 1, 3}, m.Standings())
+}
+
+func (s *MatchTestSuite) TestPlayMatch(c *check.C) {
+	rules := score.DefaultRuleSet().Copy("rotating")
+	rules.Dealer = score.DealerRules{}
+	m := NewMatch(rules, matchPlayers, 42)
+
+	_, err := m.FinishHand()
+	assert.Equal(c, ErrHandNotFinished, err)
+
+	for hand := 0; !m.Finished; hand++ {
+		if hand > 16 {
+			c.Fatal("match doesn't end")
+		}
+		g, err := m.StartHand()
+		assert.Nil(c, err)
+		assert.Equal(c, m.Dealer, g.Dealer())
+		assert.Equal(c, m.RoundWind, g.RoundWind())
+
+		_, err = m.StartHand()
+		assert.Equal(c, ErrHandNotFinished, err)
+
+		playOut(c, g)
+		record, err := m.FinishHand()
+		assert.Nil(c, err)
+		assert.Equal(c, hand+1, record.Number)
+		assert.Equal(c, int64(42+hand), record.Seed)
+	}
+
+	assert.Len(c, m.History, 16)
+	winds := []score.Tile{score.WindEast, score.WindSouth, score.WindWest, score.WindNorth}
+	for idx, record := range m.History {
+		assert.Equal(c, winds[idx/4], record.RoundWind)
+		assert.Equal(c, idx%4, record.Dealer)
+	}
+
+	_, err = m.StartHand()
+	assert.Equal(c, ErrMatchFinished, err)
+}
+
+func (s *MatchTestSuite) TestSaveLoad(c *check.C) {
+	m := NewMatch(score.DefaultRuleSet(), matchPlayers, 7)
+	g, err := m.StartHand()
+	assert.Nil(c, err)
+	playOut(c, g)
+	_, err = m.FinishHand()
+	assert.Nil(c, err)
+
+	buf := bytes.Buffer{}
+	assert.Nil(c, m.Save(&buf))
+	loaded, err := LoadMatch(&buf)
+	if !assert.Nil(c, err) {
+		c.FailNow()
+	}
+
+	assert.Equal(c, m.Players, loaded.Players)
+	assert.Equal(c, m.Dealer, loaded.Dealer)
+	assert.Equal(c, m.Scores, loaded.Scores)
+	assert.Len(c, loaded.History, 1)
+	assert.Equal(c, m.History[0].Result.Winner, loaded.History[0].Result.Winner)
+	assert.Equal(c, score.DefaultRuleSetName, loaded.Rules().Name)
+
+	// Playing on from the loaded match deals the same wall as the original would.
+	next, err := loaded.StartHand()
+	assert.Nil(c, err)
+	original, err := m.StartHand()
+	assert.Nil(c, err)
+	assert.Equal(c, original.Player(0).Concealed, next.Player(0).Concealed)
+}
@@ -0,0 +1,108 @@
+package game
+
+import (
+	"sort"
+
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+// Player is the state of one seat at the table.
+type Player struct {
+	Wind      score.Tile   `json:"wind"`
+	Concealed []score.Tile `json:"concealed"` // Private tiles, in tile order.
+	Melds     []score.Set  `json:"melds"`     // Claimed sets, and declared kongs.
+	Bonus     []score.Tile `json:"bonus"`     // Flowers and seasons.
+	Discards  []score.Tile `json:"discards"`  // Discarded tiles that weren't claimed.
+}
+
+// count returns how many of the tile the player holds concealed.
+func (p *Player) count(tile score.Tile) int {
+	count := 0
+	for _, held := range p.Concealed {
+		if held == tile {
+			count++
+		}
+	}
+	return count
+}
+
+// has returns true when the player holds all the tiles concealed.
+func (p *Player) has(tiles ...score.Tile) bool {
+	needed := map[score.Tile]int{}
+	for _, tile := range tiles {
+		needed[tile]++
+	}
+	for tile, count := range needed {
+		if p.count(tile) < count {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Player) add(tile score.Tile) {
+	p.Concealed = append(p.Concealed, tile)
+	sort.Sort(score.ByTileOrder(p.Concealed))
+}
+
+// remove takes the tiles out of the concealed tiles. The caller must make sure they're there.
+func (p *Player) remove(tiles ...score.Tile) {
+	for _, tile := range tiles {
+		for idx, held := range p.Concealed {
+			if held == tile {
+				p.Concealed = append(p.Concealed[:idx], p.Concealed[idx+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// meldedPung returns the index of the melded pung of the tile, or -1 if there is none.
+func (p *Player) meldedPung(tile score.Tile) int {
+	for idx, meld := range p.Melds {
+		if len(meld.Tiles) == 3 && !meld.Concealed && meld.Tiles[0] == tile && meld.Tiles[1] == tile {
+			return idx
+		}
+	}
+	return -1
+}
+
+// isWinning returns true when the concealed tiles and melds form a winning hand.
+func (p *Player) isWinning() bool {
+	return score.ShantenTiles(p.Concealed, len(p.Melds)) == -1
+}
+
+// visibleTiles returns the tiles of the player that everybody can see.
+func (p *Player) visibleTiles() []score.Tile {
+	tiles := append([]score.Tile{}, p.Discards...)
+	for _, meld := range p.Melds {
+		tiles = append(tiles, meld.Tiles...)
+	}
+	return tiles
+}
+
+// losingHand returns the hand of a player that didn't win, for scoring. Next to the
+// melds, concealed pungs, kongs and pillows count; other concealed tiles don't.
+func (p *Player) losingHand(windRound score.Tile) score.Hand {
+	hand := score.Hand{
+		Sets:      append([]score.Set{}, p.Melds...),
+		Bonus:     p.Bonus,
+		WindOwn:   p.Wind,
+		WindRound: windRound,
+	}
+
+	counts := map[score.Tile]int{}
+	for _, tile := range p.Concealed {
+		counts[tile]++
+	}
+	for _, tile := range score.PlayingTiles() {
+		if count := counts[tile]; count >= 2 {
+			tiles := make([]score.Tile, count)
+			for idx := range tiles {
+				tiles[idx] = tile
+			}
+			hand.Sets = append(hand.Sets, score.Set{Tiles: tiles, Concealed: true})
+		}
+	}
+	return hand
+}
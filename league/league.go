@@ -0,0 +1,244 @@
+/*
+ * Tournaments between the players of a club, like a monthly league.
+ *
+ * Every round, the players are seated at tables of four, such that they meet
+ * each other as evenly as possible. The points each player ended a match with
+ * are recorded per table, and the standings follow from those with one of the
+ * ranking methods:
+ *
+ * - raw: the points themselves are added up;
+ * - placement: each place at the table is worth a fixed number of points;
+ * - uma: the points minus the return points, in thousands, plus the uma for
+ *   the place, where the winner also takes the oka, which is the difference
+ *   between the return and the starting points of all four players. Matches
+ *   here start at 0 points, so by default there are no starting and return
+ *   points, and with that no oka.
+ *
+ * Players with equal points at a table share their places, and with that the
+ * placement points and uma.
+ */
+
+package league
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/storage"
+)
+
+// The ranking methods.
+const (
+	MethodRaw       = "raw"
+	MethodPlacement = "placement"
+	MethodUma       = "uma"
+)
+
+// Standard errors.
+var (
+	ErrUnknownMethod = errors.New("unknown ranking method")
+	ErrNoSuchTable   = errors.New("no such table")
+	ErrTooFewPlayers = errors.New("a tournament needs at least four players")
+)
+
+// DefaultRanking returns the ranking rules of the method, with common values
+// for the placement points and uma.
+func DefaultRanking(method string) (storage.RankingRules, error) {
+	switch method {
+	case "", MethodRaw:
+		return storage.RankingRules{Method: MethodRaw}, nil
+	case MethodPlacement:
+		return storage.RankingRules{
+			Method:    MethodPlacement,
+			Placement: [score.NumPlayers]float64{3, 2, 1, 0},
+		}, nil
+	case MethodUma:
+		return storage.RankingRules{
+			Method: MethodUma,
+			Uma:    [score.NumPlayers]float64{15, 5, -5, -15},
+		}, nil
+	}
+	return storage.RankingRules{}, fmt.Errorf("%q: %s", method, ErrUnknownMethod)
+}
+
+// WithDefaults returns the ranking rules, where the values that weren't given
+// are taken from the defaults of the method.
+func WithDefaults(ranking storage.RankingRules) (storage.RankingRules, error) {
+	defaults, err := DefaultRanking(ranking.Method)
+	if err != nil {
+		return ranking, err
+	}
+	ranking.Method = defaults.Method
+	if ranking.Placement == [score.NumPlayers]float64{} {
+		ranking.Placement = defaults.Placement
+	}
+	if ranking.Uma == [score.NumPlayers]float64{} {
+		ranking.Uma = defaults.Uma
+	}
+	if ranking.StartingPoints == 0 && ranking.ReturnPoints == 0 {
+		ranking.StartingPoints = defaults.StartingPoints
+		ranking.ReturnPoints = defaults.ReturnPoints
+	}
+	return ranking, nil
+}
+
+// New returns a tournament between the players, without rounds yet. The ranking
+// rules are completed with the defaults of their method.
+func New(name, ruleSet string, players []storage.ID, ranking storage.RankingRules) (*storage.Tournament, error) {
+	if len(players) < score.NumPlayers {
+		return nil, ErrTooFewPlayers
+	}
+	if err := storage.UniquePlayers(players); err != nil {
+		return nil, err
+	}
+	ranking, err := WithDefaults(ranking)
+	if err != nil {
+		return nil, err
+	}
+	return &storage.Tournament{
+		Name:    name,
+		RuleSet: ruleSet,
+		Players: append([]storage.ID{}, players...),
+		Ranking: ranking,
+		Rounds:  []storage.Round{},
+	}, nil
+}
+
+// RecordResult records the points the players at the table ended their match with.
+// Rounds and tables are counted from 0. Recording a result again replaces the earlier one.
+func RecordResult(tournament *storage.Tournament, round, table int, scores [score.NumPlayers]int) error {
+	if round < 0 || round >= len(tournament.Rounds) {
+		return fmt.Errorf("round %d: %s", round+1, ErrNoSuchTable)
+	}
+	tables := tournament.Rounds[round].Tables
+	if table < 0 || table >= len(tables) {
+		return fmt.Errorf("round %d, table %d: %s", round+1, table+1, ErrNoSuchTable)
+	}
+	tables[table].Scores = &scores
+	return nil
+}
+
+// Complete returns true when all tables of all rounds have been played.
+func Complete(tournament *storage.Tournament) bool {
+	for _, round := range tournament.Rounds {
+		for _, table := range round.Tables {
+			if table.Scores == nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Standing is how a player is doing in the tournament.
+type Standing struct {
+	Rank    int                   `json:"rank"` // Players with the same points share the rank.
+	Player  storage.ID            `json:"player"`
+	Points  float64               `json:"points"` // Ranking points, according to the ranking method.
+	Score   int                   `json:"score"`  // Total of the points at the end of the matches.
+	Matches int                   `json:"matches"`
+	Places  [score.NumPlayers]int `json:"places"` // How often the player took each place.
+}
+
+// places returns the place of each player at the table, counted from 0, and
+// how many players share it. Players with equal scores share the better place.
+func places(scores [score.NumPlayers]int) (place, shared [score.NumPlayers]int) {
+	for player, own := range scores {
+		for other, theirs := range scores {
+			switch {
+			case theirs > own:
+				place[player]++
+			case theirs == own && other != player:
+				shared[player]++
+			}
+		}
+		shared[player]++
+	}
+	return place, shared
+}
+
+// sharedBonus returns the average of the bonuses of the places that are shared.
+func sharedBonus(bonuses [score.NumPlayers]float64, place, shared int) float64 {
+	total := 0.0
+	for idx := place; idx < place+shared; idx++ {
+		total += bonuses[idx]
+	}
+	return total / float64(shared)
+}
+
+// rankingPoints returns the ranking points of each player at the table.
+func rankingPoints(ranking storage.RankingRules, scores [score.NumPlayers]int) ([score.NumPlayers]float64, error) {
+	points := [score.NumPlayers]float64{}
+	place, shared := places(scores)
+
+	switch ranking.Method {
+	case MethodRaw:
+		for player, final := range scores {
+			points[player] = float64(final)
+		}
+	case MethodPlacement:
+		for player := range scores {
+			points[player] = sharedBonus(ranking.Placement, place[player], shared[player])
+		}
+	case MethodUma:
+		bonuses := ranking.Uma
+		bonuses[0] += float64(score.NumPlayers*(ranking.ReturnPoints-ranking.StartingPoints)) / 1000
+		for player, final := range scores {
+			points[player] = float64(final-ranking.ReturnPoints)/1000 +
+				sharedBonus(bonuses, place[player], shared[player])
+		}
+	default:
+		return points, fmt.Errorf("%q: %s", ranking.Method, ErrUnknownMethod)
+	}
+	return points, nil
+}
+
+// Standings returns the standings of all players, from first to last. Tables
+// that haven't been played yet don't count.
+func Standings(tournament *storage.Tournament) ([]Standing, error) {
+	standings := make([]Standing, len(tournament.Players))
+	byPlayer := map[storage.ID]*Standing{}
+	for idx, player := range tournament.Players {
+		standings[idx].Player = player
+		byPlayer[player] = &standings[idx]
+	}
+
+	for _, round := range tournament.Rounds {
+		for _, table := range round.Tables {
+			if table.Scores == nil {
+				continue
+			}
+			points, err := rankingPoints(tournament.Ranking, *table.Scores)
+			if err != nil {
+				return nil, err
+			}
+			place, _ := places(*table.Scores)
+			for seat, player := range table.Players {
+				standing, found := byPlayer[player]
+				if !found {
+					return nil, fmt.Errorf("player %d played without being registered", player)
+				}
+				standing.Points += points[seat]
+				standing.Score += table.Scores[seat]
+				standing.Matches++
+				standing.Places[place[seat]]++
+			}
+		}
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		if standings[i].Points != standings[j].Points {
+			return standings[i].Points > standings[j].Points
+		}
+		return standings[i].Score > standings[j].Score
+	})
+	for idx := range standings {
+		standings[idx].Rank = idx + 1
+		if idx > 0 && standings[idx].Points == standings[idx-1].Points {
+			standings[idx].Rank = standings[idx-1].Rank
+		}
+	}
+	return standings, nil
+}
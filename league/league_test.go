@@ -0,0 +1,149 @@
+package league
+
+import (
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/storage"
+)
+
+type LeagueTestSuite struct{}
+
+var _ = check.Suite(&LeagueTestSuite{})
+
+// tournament returns a tournament of four players, with one round of one table.
+func tournament(c *check.C, method string) *storage.Tournament {
+	t, err := New("Test", score.DefaultRuleSetName, []storage.ID{1, 2, 3, 4}, storage.RankingRules{Method: method})
+	if !assert.Nil(c, err) {
+		c.FailNow()
+	}
+	t.Rounds = []storage.Round{{Tables: []storage.Table{{Players: [score.NumPlayers]storage.ID{1, 2, 3, 4}}}}}
+	return t
+}
+
+func (s *LeagueTestSuite) TestNew(c *check.C) {
+	_, err := New("Test", "", []storage.ID{1, 2, 3}, storage.RankingRules{})
+	assert.Equal(c, ErrTooFewPlayers, err)
+	_, err = New("Test", "", []storage.ID{1, 1, 2, 3}, storage.RankingRules{})
+	assert.Contains(c, err.Error(), storage.ErrDuplicatePlayer.Error())
+	_, err = New("Test", "", []storage.ID{1, 2, 3, 4}, storage.RankingRules{Method: "elo"})
+	assert.Contains(c, err.Error(), ErrUnknownMethod.Error())
+
+	t, err := New("Test", "", []storage.ID{1, 2, 3, 4}, storage.RankingRules{})
+	assert.Nil(c, err)
+	assert.Equal(c, MethodRaw, t.Ranking.Method)
+
+	// Given values are kept, the others are defaults.
+	t, err = New("Test", "", []storage.ID{1, 2, 3, 4}, storage.RankingRules{
+		Method: MethodUma, Uma: [score.NumPlayers]float64{20, 10, -10, -20}})
+	assert.Nil(c, err)
+	assert.Equal(c, [score.NumPlayers]float64{20, 10, -10, -20}, t.Ranking.Uma)
+	assert.Equal(c, 0, t.Ranking.StartingPoints)
+	assert.Equal(c, 0, t.Ranking.ReturnPoints)
+
+	t, err = New("Test", "", []storage.ID{1, 2, 3, 4}, storage.RankingRules{
+		Method: MethodUma, StartingPoints: 25000, ReturnPoints: 30000})
+	assert.Nil(c, err)
+	assert.Equal(c, [score.NumPlayers]float64{15, 5, -5, -15}, t.Ranking.Uma)
+	assert.Equal(c, 25000, t.Ranking.StartingPoints)
+	assert.Equal(c, 30000, t.Ranking.ReturnPoints)
+}
+
+func (s *LeagueTestSuite) TestRecordResult(c *check.C) {
+	t := tournament(c, MethodRaw)
+	assert.False(c, Complete(t))
+	assert.Contains(c, RecordResult(t, 1, 0, [score.NumPlayers]int{}).Error(), ErrNoSuchTable.Error())
+	assert.Contains(c, RecordResult(t, 0, 1, [score.NumPlayers]int{}).Error(), ErrNoSuchTable.Error())
+	assert.Nil(c, RecordResult(t, 0, 0, [score.NumPlayers]int{10, 20, 30, -60}))
+	assert.True(c, Complete(t))
+}
+
+func (s *LeagueTestSuite) TestRaw(c *check.C) {
+	t := tournament(c, MethodRaw)
+	assert.Nil(c, RecordResult(t, 0, 0, [score.NumPlayers]int{-40, 120, -40, -40}))
+
+	standings, err := Standings(t)
+	assert.Nil(c, err)
+	assert.Equal(c, Standing{Rank: 1, Player: 2, Points: 120, Score: 120, Matches: 1, Places: [4]int{1, 0, 0, 0}},
+		standings[0])
+	// The others share the second place.
+	for _, standing := range standings[1:] {
+		assert.Equal(c, 2, standing.Rank)
+		assert.Equal(c, [4]int{0, 1, 0, 0}, standing.Places)
+		assert.Equal(c, -40.0, standing.Points)
+	}
+}
+
+func (s *LeagueTestSuite) TestPlacement(c *check.C) {
+	t := tournament(c, MethodPlacement)
+	// Player 1 and 3 share the second and third place.
+	assert.Nil(c, RecordResult(t, 0, 0, [score.NumPlayers]int{0, 50, 0, -50}))
+
+	standings, err := Standings(t)
+	assert.Nil(c, err)
+	points := map[storage.ID]float64{}
+	for _, standing := range standings {
+		points[standing.Player] = standing.Points
+	}
+	assert.Equal(c, map[storage.ID]float64{1: 1.5, 2: 3, 3: 1.5, 4: 0}, points)
+	assert.Equal(c, storage.ID(2), standings[0].Player)
+	assert.Equal(c, 2, standings[2].Rank)
+}
+
+func (s *LeagueTestSuite) TestUma(c *check.C) {
+	// Matches start at 0 points, so by default only the uma is added.
+	t := tournament(c, MethodUma)
+	assert.Nil(c, RecordResult(t, 0, 0, [score.NumPlayers]int{-2000, 4500, 500, -3000}))
+
+	standings, err := Standings(t)
+	assert.Nil(c, err)
+	points := map[storage.ID]float64{}
+	total := 0.0
+	for _, standing := range standings {
+		points[standing.Player] = standing.Points
+		total += standing.Points
+	}
+	assert.InDelta(c, 4.5+15, points[2], 0.001)
+	assert.InDelta(c, 0.5+5, points[3], 0.001)
+	assert.InDelta(c, -2-5, points[1], 0.001)
+	assert.InDelta(c, -3-15, points[4], 0.001)
+	assert.InDelta(c, 0.0, total, 0.001)
+}
+
+func (s *LeagueTestSuite) TestUmaWithOka(c *check.C) {
+	t := tournament(c, MethodUma)
+	t.Ranking.StartingPoints = 25000
+	t.Ranking.ReturnPoints = 30000
+	assert.Nil(c, RecordResult(t, 0, 0, [score.NumPlayers]int{20000, 45000, 25000, 10000}))
+
+	standings, err := Standings(t)
+	assert.Nil(c, err)
+	expected := []struct {
+		player storage.ID
+		points float64
+	}{
+		{2, 45 - 30 + 15 + 20}, // Including the oka of 4×5000.
+		{3, 25 - 30 + 5},
+		{1, 20 - 30 - 5},
+		{4, 10 - 30 - 15},
+	}
+	total := 0.0
+	for idx, standing := range standings {
+		assert.Equal(c, expected[idx].player, standing.Player)
+		assert.InDelta(c, expected[idx].points, standing.Points, 0.001)
+		total += standing.Points
+	}
+	// With everybody starting at 25000, the points of all players add up to zero.
+	assert.InDelta(c, 0.0, total, 0.001)
+}
+
+func (s *LeagueTestSuite) TestUnplayedTablesDontCount(c *check.C) {
+	t := tournament(c, MethodPlacement)
+	standings, err := Standings(t)
+	assert.Nil(c, err)
+	for _, standing := range standings {
+		assert.Equal(c, 0, standing.Matches)
+		assert.Equal(c, 1, standing.Rank)
+	}
+}
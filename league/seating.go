@@ -0,0 +1,149 @@
+package league
+
+import (
+	"math/rand"
+
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/storage"
+)
+
+// seatingAttempts is the number of random seatings that are improved on to seat a new round.
+const seatingAttempts = 50
+
+type pair struct {
+	a, b storage.ID
+}
+
+func newPair(a, b storage.ID) pair {
+	if a > b {
+		a, b = b, a
+	}
+	return pair{a, b}
+}
+
+// history counts how often players met each other and sat out in earlier rounds.
+type history struct {
+	meetings map[pair]int
+	byes     map[storage.ID]int
+}
+
+func newHistory(rounds []storage.Round) history {
+	h := history{map[pair]int{}, map[storage.ID]int{}}
+	for _, round := range rounds {
+		for _, table := range round.Tables {
+			h.meet(table.Players[:], 1)
+		}
+		for _, player := range round.Byes {
+			h.byes[player]++
+		}
+	}
+	return h
+}
+
+// meet adds the count to the meetings of every two players at the table.
+func (h history) meet(players []storage.ID, count int) {
+	for i, a := range players {
+		for _, b := range players[i+1:] {
+			h.meetings[newPair(a, b)] += count
+		}
+	}
+}
+
+// cost is how unbalanced the table makes the meetings. Meeting somebody again
+// costs more the more often they've met already.
+func (h history) cost(players []storage.ID) int {
+	cost := 0
+	for i, a := range players {
+		for _, b := range players[i+1:] {
+			met := h.meetings[newPair(a, b)]
+			cost += met * met
+		}
+	}
+	return cost
+}
+
+// seatingCost is the cost of all tables, where every four players sit at one table.
+func (h history) seatingCost(players []storage.ID) int {
+	cost := 0
+	for start := 0; start < len(players); start += score.NumPlayers {
+		cost += h.cost(players[start : start+score.NumPlayers])
+	}
+	return cost
+}
+
+// improve swaps players between tables for as long as that lowers the cost,
+// and returns the cost of the resulting seating.
+func (h history) improve(players []storage.ID) int {
+	cost := h.seatingCost(players)
+	for improved := true; improved && cost > 0; {
+		improved = false
+		for i := range players {
+			for j := i + 1; j < len(players); j++ {
+				if i/score.NumPlayers == j/score.NumPlayers {
+					continue
+				}
+				players[i], players[j] = players[j], players[i]
+				if swapped := h.seatingCost(players); swapped < cost {
+					cost, improved = swapped, true
+				} else {
+					players[i], players[j] = players[j], players[i]
+				}
+			}
+		}
+	}
+	return cost
+}
+
+// chooseByes picks the players that sit out, preferring those who sat out least often.
+func (h history) chooseByes(players []storage.ID, rng *rand.Rand) (byes, playing []storage.ID) {
+	numByes := len(players) % score.NumPlayers
+	shuffled := append([]storage.ID{}, players...)
+	rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+	for len(byes) < numByes {
+		fewest := -1
+		for idx, player := range shuffled {
+			if fewest < 0 || h.byes[player] < h.byes[shuffled[fewest]] {
+				fewest = idx
+			}
+		}
+		byes = append(byes, shuffled[fewest])
+		shuffled = append(shuffled[:fewest], shuffled[fewest+1:]...)
+	}
+	return byes, shuffled
+}
+
+// AddRound seats the players for a new round, and adds it to the tournament.
+// The players are seated such that they meet each other as evenly as possible;
+// when their number isn't a multiple of four, those who sat out least often sit
+// out this round. The seed determines the seating among equally good ones.
+func AddRound(tournament *storage.Tournament, seed int64) (*storage.Round, error) {
+	if len(tournament.Players) < score.NumPlayers {
+		return nil, ErrTooFewPlayers
+	}
+	rng := rand.New(rand.NewSource(seed))
+	h := newHistory(tournament.Rounds)
+	byes, playing := h.chooseByes(tournament.Players, rng)
+
+	var best []storage.ID
+	bestCost := -1
+	for attempt := 0; attempt < seatingAttempts && bestCost != 0; attempt++ {
+		rng.Shuffle(len(playing), func(i, j int) { playing[i], playing[j] = playing[j], playing[i] })
+		cost := h.improve(playing)
+		if bestCost < 0 || cost < bestCost {
+			best, bestCost = append([]storage.ID{}, playing...), cost
+		}
+	}
+
+	round := storage.Round{Byes: byes}
+	if round.Byes == nil {
+		round.Byes = []storage.ID{}
+	}
+	for start := 0; start < len(best); start += score.NumPlayers {
+		table := storage.Table{}
+		copy(table.Players[:], best[start:start+score.NumPlayers])
+		round.Tables = append(round.Tables, table)
+	}
+	tournament.Rounds = append(tournament.Rounds, round)
+	return &tournament.Rounds[len(tournament.Rounds)-1], nil
+}
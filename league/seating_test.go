@@ -0,0 +1,92 @@
+package league
+
+import (
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+
+	"github.com/sybrenstuvel/mahjong/storage"
+)
+
+type SeatingTestSuite struct{}
+
+var _ = check.Suite(&SeatingTestSuite{})
+
+func players(count int) []storage.ID {
+	ids := []storage.ID{}
+	for id := 1; id <= count; id++ {
+		ids = append(ids, storage.ID(id))
+	}
+	return ids
+}
+
+func (s *SeatingTestSuite) TestEveryoneSeatedOnce(c *check.C) {
+	t, err := New("Test", "", players(14), storage.RankingRules{})
+	assert.Nil(c, err)
+
+	round, err := AddRound(t, 1)
+	assert.Nil(c, err)
+	assert.Len(c, round.Tables, 3)
+	assert.Len(c, round.Byes, 2)
+
+	seen := map[storage.ID]int{}
+	for _, table := range round.Tables {
+		for _, player := range table.Players {
+			seen[player]++
+		}
+	}
+	for _, player := range round.Byes {
+		seen[player]++
+	}
+	assert.Len(c, seen, 14)
+	for player, count := range seen {
+		assert.Equal(c, 1, count, "player %d", player)
+	}
+}
+
+func (s *SeatingTestSuite) TestByesAreSpread(c *check.C) {
+	t, err := New("Test", "", players(6), storage.RankingRules{})
+	assert.Nil(c, err)
+
+	// With two byes per round, everybody sits out once in three rounds.
+	byes := map[storage.ID]int{}
+	for round := 0; round < 3; round++ {
+		added, err := AddRound(t, int64(round))
+		assert.Nil(c, err)
+		for _, player := range added.Byes {
+			byes[player]++
+		}
+	}
+	assert.Len(c, byes, 6)
+}
+
+func (s *SeatingTestSuite) TestBalancedMeetings(c *check.C) {
+	// 16 players can play 5 rounds in which nobody meets anybody twice, as every
+	// player meets 3 others per round. The seating doesn't need to be perfect,
+	// but shouldn't let anybody meet somebody three times.
+	t, err := New("Test", "", players(16), storage.RankingRules{})
+	assert.Nil(c, err)
+	for round := 0; round < 5; round++ {
+		_, err := AddRound(t, int64(round))
+		assert.Nil(c, err)
+	}
+
+	h := newHistory(t.Rounds)
+	for pair, met := range h.meetings {
+		assert.True(c, met <= 2, "%v met %d times", pair, met)
+	}
+	// Everybody played every round.
+	for _, round := range t.Rounds {
+		assert.Len(c, round.Tables, 4)
+		assert.Empty(c, round.Byes)
+	}
+}
+
+func (s *SeatingTestSuite) TestReproducible(c *check.C) {
+	first, _ := New("Test", "", players(12), storage.RankingRules{})
+	second, _ := New("Test", "", players(12), storage.RankingRules{})
+	for round := 0; round < 3; round++ {
+		AddRound(first, 42)
+		AddRound(second, 42)
+	}
+	assert.Equal(c, first.Rounds, second.Rounds)
+}
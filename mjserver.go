@@ -1,15 +1,22 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	stdlog "log"
 
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/storage"
+	"github.com/sybrenstuvel/mahjong/table"
 	"github.com/sybrenstuvel/mahjong/web"
 )
 
@@ -19,12 +26,20 @@ var cliArgs struct {
 	version bool
 	verbose bool
 	debug   bool
+	rules   string
+	timeout time.Duration
+	idle    time.Duration
+	db      string
 }
 
 func parseCliArgs() {
 	flag.BoolVar(&cliArgs.version, "version", false, "Shows the application version, then exits.")
 	flag.BoolVar(&cliArgs.verbose, "verbose", false, "Enable info-level logging.")
 	flag.BoolVar(&cliArgs.debug, "debug", false, "Enable debug-level logging.")
+	flag.StringVar(&cliArgs.rules, "rules", "", "YAML file with house rules to score with by default.")
+	flag.DurationVar(&cliArgs.timeout, "turn-timeout", 30*time.Second, "Time players at a table get for each action.")
+	flag.DurationVar(&cliArgs.idle, "idle-timeout", table.DefaultIdleTimeout, "Time after which a table that nobody joined is closed.")
+	flag.StringVar(&cliArgs.db, "db", "", "BoltDB file to record scored hands in; without it, they are forgotten when the server stops.")
 	flag.Parse()
 }
 
@@ -54,6 +69,45 @@ func logStartup() {
 	}).Info("Starting Mahjong Server")
 }
 
+// loadRules registers the house rules from the rules file, and returns the
+// name of the rule set to use by default.
+func loadRules() string {
+	if cliArgs.rules == "" {
+		return score.DefaultRuleSetName
+	}
+
+	rules, err := score.LoadRuleSet(cliArgs.rules)
+	if err != nil {
+		// Logging would escape the newlines of a multi-line error.
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := score.RegisterRuleSet(rules); err != nil {
+		log.Fatalf("Unable to use rules from %s: %s", cliArgs.rules, err)
+	}
+
+	log.WithFields(log.Fields{
+		"file":    cliArgs.rules,
+		"ruleset": rules.Name,
+	}).Info("Loaded house rules")
+	return rules.Name
+}
+
+// openStore opens the database, or falls back to keeping the history in memory.
+func openStore() storage.Store {
+	if cliArgs.db == "" {
+		log.Warning("No database given with -db, history is kept in memory only")
+		return storage.NewMemory()
+	}
+
+	store, err := storage.OpenBolt(cliArgs.db)
+	if err != nil {
+		log.Fatalf("Unable to open database %s: %s", cliArgs.db, err)
+	}
+	log.WithField("file", cliArgs.db).Info("Opened database")
+	return store
+}
+
 func main() {
 	parseCliArgs()
 	if cliArgs.version {
@@ -63,6 +117,8 @@ func main() {
 
 	configLogging()
 	logStartup()
+	defaultRuleSet := loadRules()
+	store := openStore()
 
 	// Set some more or less sensible limits & timeouts.
 	http.DefaultTransport = &http.Transport{
@@ -76,12 +132,45 @@ func main() {
 	// router.HandleFunc("/", index)
 	// router.HandleFunc("/score", scoreHand)
 
-	pages := web.CreatePageHandler(serverVersion)
+	pages := web.CreatePageHandler(serverVersion, defaultRuleSet, store)
 	pages.AddRoutes(router)
 
+	lobby := table.NewLobby(cliArgs.timeout, cliArgs.idle)
+	tables := web.CreateTableHandler(lobby, defaultRuleSet)
+	tables.AddRoutes(router)
+
 	listen := ":8080"
+	server := &http.Server{Addr: listen, Handler: router}
+	stopped := make(chan struct{})
+	go shutdownOnSignal(server, lobby, store, stopped)
+
 	log.Println("Listening on", listen)
-	log.Fatal(http.ListenAndServe(listen, router))
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-stopped
+}
+
+// shutdownOnSignal closes the tables, stops the server and closes the database on
+// SIGINT or SIGTERM. The stopped channel is closed when all is done.
+func shutdownOnSignal(server *http.Server, lobby *table.Lobby, store storage.Store, stopped chan<- struct{}) {
+	defer close(stopped)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	sig := <-signals
+	log.WithField("signal", sig).Info("Shutting down")
+
+	// Closing the tables disconnects the players, so that the server can stop.
+	lobby.Shutdown()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.WithError(err).Warning("Unable to shut down cleanly")
+	}
+	if err := store.Close(); err != nil {
+		log.WithError(err).Warning("Unable to close the database")
+	}
 }
 
 func todoShow(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,209 @@
+/*
+ * Rendering of hands as SVG images, for sharing them or showing them on a page.
+ *
+ * The tiles are laid out in one row, set by set: first the concealed sets,
+ * then the melded ones, and then the flowers and seasons. Melded sets have
+ * their first tile lying on its side, as when it was claimed from another
+ * player, and concealed kongs show their outer tiles face down. Each tile
+ * shows its Unicode mahjong glyph, with its notation in the corner for viewers
+ * without a font that has those glyphs.
+ */
+
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+// Sizes in pixels.
+const (
+	tileWidth   = 36
+	tileHeight  = 48
+	tileGap     = 2  // Between the tiles of a set.
+	setGap      = 14 // Between sets.
+	margin      = 8
+	captionSize = 14
+)
+
+// Colours and fonts.
+const (
+	colourFace   = "#f5f0e1"
+	colourBack   = "#2e7d5b"
+	colourBorder = "#333333"
+	colourLabel  = "#666666"
+	glyphFont    = "FreeSerif, Symbola, 'Segoe UI Symbol', 'Noto Sans Symbols 2', serif"
+	labelFont    = "sans-serif"
+)
+
+var windNames = map[score.Tile]string{
+	score.WindEast:  "East",
+	score.WindSouth: "South",
+	score.WindWest:  "West",
+	score.WindNorth: "North",
+}
+
+// Glyph returns the Unicode mahjong glyph of the tile, or the replacement
+// character for tiles that aren't valid.
+func Glyph(tile score.Tile) rune {
+	if !tile.IsValid() {
+		return '\uFFFD'
+	}
+	number := rune(tile % 10)
+	switch {
+	case tile.IsWind():
+		return 0x1F000 + number - 1
+	case tile.IsDragon():
+		return 0x1F004 + number - 1
+	case tile.IsFlower():
+		return 0x1F022 + number - 1
+	case tile.IsSeason():
+		return 0x1F026 + number - 1
+	case tile < score.Chars1:
+		return 0x1F019 + number - 1
+	case tile < score.Bamboo1:
+		return 0x1F007 + number - 1
+	}
+	return 0x1F010 + number - 1
+}
+
+// placedTile is a tile with its position in the image.
+type placedTile struct {
+	tile     score.Tile
+	x        int
+	sideways bool // Lying on its side, as the claimed tile of a meld.
+	faceDown bool
+}
+
+// layout returns the position of every tile, and the width of the row.
+func layout(hand *score.Hand) ([]placedTile, int) {
+	placed := []placedTile{}
+	x := margin
+	addGroup := func(tiles []score.Tile, melded, concealedKong bool) {
+		if len(placed) > 0 {
+			x += setGap - tileGap
+		}
+		for idx, tile := range tiles {
+			place := placedTile{tile: tile, x: x}
+			place.sideways = melded && idx == 0
+			place.faceDown = concealedKong && (idx == 0 || idx == len(tiles)-1)
+			placed = append(placed, place)
+			if place.sideways {
+				x += tileHeight + tileGap
+			} else {
+				x += tileWidth + tileGap
+			}
+		}
+	}
+
+	for _, concealed := range []bool{true, false} {
+		for idx := range hand.Sets {
+			set := &hand.Sets[idx]
+			if set.Concealed != concealed || len(set.Tiles) == 0 {
+				continue
+			}
+			addGroup(set.Tiles, !concealed, concealed && isKong(set.Tiles))
+		}
+	}
+	if len(hand.Bonus) > 0 {
+		addGroup(hand.Bonus, false, false)
+	}
+
+	if len(placed) > 0 {
+		x -= tileGap
+	}
+	return placed, x + margin
+}
+
+func isKong(tiles []score.Tile) bool {
+	if len(tiles) != 4 {
+		return false
+	}
+	for _, tile := range tiles[1:] {
+		if tile != tiles[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// caption returns the text under the tiles, or an empty string when there is nothing to say.
+func caption(hand *score.Hand) string {
+	parts := []string{}
+	if name, found := windNames[hand.WindOwn]; found {
+		parts = append(parts, name+" seat")
+	}
+	if name, found := windNames[hand.WindRound]; found {
+		parts = append(parts, name+" round")
+	}
+	if hand.WinSelfDrawn {
+		parts = append(parts, "self-drawn")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeTile writes one tile with its top-left corner at the origin.
+func writeTile(w io.Writer, place placedTile) {
+	if place.faceDown {
+		fmt.Fprintf(w, `<rect width="%d" height="%d" rx="4" fill="%s" stroke="%s"/>`,
+			tileWidth, tileHeight, colourBack, colourBorder)
+		return
+	}
+	fmt.Fprintf(w, `<rect width="%d" height="%d" rx="4" fill="%s" stroke="%s"/>`,
+		tileWidth, tileHeight, colourFace, colourBorder)
+	fmt.Fprintf(w, `<text x="%d" y="%d" font-family="%s" font-size="%d" text-anchor="middle">%c</text>`,
+		tileWidth/2, tileHeight-14, glyphFont, tileWidth, Glyph(place.tile))
+	fmt.Fprintf(w, `<text x="%d" y="%d" font-family="%s" font-size="9" fill="%s" text-anchor="end">%s</text>`,
+		tileWidth-3, tileHeight-3, labelFont, colourLabel, score.FormatTiles([]score.Tile{place.tile}))
+}
+
+// Hand writes the hand as an SVG image.
+func Hand(w io.Writer, hand *score.Hand) error {
+	for _, set := range hand.Sets {
+		for _, tile := range set.Tiles {
+			if !tile.IsValid() {
+				return fmt.Errorf("%d: %s", tile, score.ErrTileNotValid)
+			}
+		}
+	}
+	for _, tile := range hand.Bonus {
+		if !tile.IsBonus() {
+			return fmt.Errorf("%d: %s", tile, score.ErrTileNotValid)
+		}
+	}
+
+	placed, width := layout(hand)
+	text := caption(hand)
+	height := margin + tileHeight + margin
+	if text != "" {
+		height += captionSize + margin/2
+	}
+
+	buf := bytes.Buffer{}
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		width, height, width, height)
+	fmt.Fprintln(&buf)
+	for _, place := range placed {
+		if place.sideways {
+			// Rotated around the bottom-left corner, so that it lies on the same baseline.
+			fmt.Fprintf(&buf, `<g transform="translate(%d %d) rotate(-90)">`, place.x, margin+tileHeight)
+		} else {
+			fmt.Fprintf(&buf, `<g transform="translate(%d %d)">`, place.x, margin)
+		}
+		writeTile(&buf, place)
+		fmt.Fprintln(&buf, `</g>`)
+	}
+	if text != "" {
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" font-family="%s" font-size="%d">%s</text>`,
+			margin, height-margin, labelFont, captionSize, text)
+		fmt.Fprintln(&buf)
+	}
+	fmt.Fprintln(&buf, `</svg>`)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,114 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+type RenderTestSuite struct{}
+
+var _ = check.Suite(&RenderTestSuite{})
+
+func parse(c *check.C, notation string) *score.Hand {
+	hand, err := score.ParseHand(notation)
+	assert.Nil(c, err)
+	return &hand
+}
+
+func (s *RenderTestSuite) TestGlyph(c *check.C) {
+	assert.Equal(c, '🀙', Glyph(score.Balls1))
+	assert.Equal(c, '🀡', Glyph(score.Balls9))
+	assert.Equal(c, '🀇', Glyph(score.Chars1))
+	assert.Equal(c, '🀘', Glyph(score.Bamboo9))
+	assert.Equal(c, '🀀', Glyph(score.WindEast))
+	assert.Equal(c, '🀃', Glyph(score.WindNorth))
+	assert.Equal(c, '🀄', Glyph(score.DragonRed))
+	assert.Equal(c, '🀆', Glyph(score.DragonWhite))
+	assert.Equal(c, '🀢', Glyph(score.Flower1))
+	assert.Equal(c, '🀩', Glyph(score.Season4))
+	assert.Equal(c, '�', Glyph(score.NoTile))
+}
+
+func (s *RenderTestSuite) TestLayout(c *check.C) {
+	placed, width := layout(parse(c, "5555c | 123b EEE 9999s 13f"))
+
+	// Concealed kongs show their outer tiles face down.
+	assert.Equal(c, score.Chars5, placed[0].tile)
+	assert.True(c, placed[0].faceDown)
+	assert.False(c, placed[1].faceDown)
+	assert.False(c, placed[2].faceDown)
+	assert.True(c, placed[3].faceDown)
+	assert.Equal(c, margin, placed[0].x)
+
+	// Melded sets have their first tile on its side.
+	assert.Equal(c, score.Balls1, placed[4].tile)
+	assert.True(c, placed[4].sideways)
+	assert.False(c, placed[5].sideways)
+	assert.Equal(c, placed[4].x+tileHeight+tileGap, placed[5].x)
+	assert.Equal(c, score.Bamboo9, placed[10].tile)
+	assert.True(c, placed[10].sideways)
+	assert.False(c, placed[10].faceDown)
+
+	// The bonus tiles are at the end, upright.
+	assert.Len(c, placed, 16)
+	assert.Equal(c, score.Flower3, placed[15].tile)
+	assert.False(c, placed[14].sideways)
+	assert.Equal(c, placed[15].x+tileWidth+margin, width)
+}
+
+func (s *RenderTestSuite) TestValidSVG(c *check.C) {
+	buf := bytes.Buffer{}
+	err := Hand(&buf, parse(c, "123b 555c EEEE rr | 789s 1f @NW"))
+	assert.Nil(c, err)
+
+	decoder := xml.NewDecoder(&buf)
+	tiles, texts := 0, []string{}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if !assert.Nil(c, err) {
+			return
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			if element.Name.Local == "rect" {
+				tiles++
+			}
+		case xml.CharData:
+			if text := strings.TrimSpace(string(element)); text != "" {
+				texts = append(texts, text)
+			}
+		}
+	}
+	assert.Equal(c, 16, tiles)
+	assert.Contains(c, texts, "🀋")
+	assert.Contains(c, texts, "5c")
+	assert.Contains(c, texts, "North seat, West round")
+	// Only the two middle tiles of the concealed kong show their face.
+	assert.Equal(c, 2, strings.Count(strings.Join(texts, " "), "🀀"))
+}
+
+func (s *RenderTestSuite) TestEmptyHand(c *check.C) {
+	buf := bytes.Buffer{}
+	assert.Nil(c, Hand(&buf, &score.Hand{}))
+	assert.Contains(c, buf.String(), `width="16"`)
+}
+
+func (s *RenderTestSuite) TestInvalidTile(c *check.C) {
+	buf := bytes.Buffer{}
+	hand := score.Hand{Sets: []score.Set{{Tiles: []score.Tile{score.Balls1, 10}}}}
+	assert.NotNil(c, Hand(&buf, &hand))
+	assert.Empty(c, buf.String())
+
+	hand = score.Hand{Bonus: []score.Tile{score.WindEast}}
+	assert.NotNil(c, Hand(&buf, &hand))
+}
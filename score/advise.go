@@ -0,0 +1,76 @@
+/*
+ * Discard advice, based on tile efficiency.
+ */
+
+package score
+
+import "sort"
+
+// Advice describes what discarding a tile does to the hand.
+type Advice struct {
+	Discard    Tile   `json:"discard"`
+	Shanten    int    `json:"shanten"`    // Shanten of the hand after the discard.
+	Acceptance []Tile `json:"acceptance"` // Tiles that lower the shanten when drawn.
+	Live       int    `json:"live"`       // Number of acceptance tiles that can still be drawn.
+}
+
+// Advise ranks every possible discard of the hand, best first. Discards that result in a
+// lower shanten are better; for the same shanten, the one leaving more live acceptance
+// tiles is better. Visible tiles are those seen outside the hand, like discards and the
+// sets melded by other players. They, and the tiles of the hand itself, can no longer be drawn.
+// A hand with more than four of a tile is rejected.
+func Advise(hand *Hand, visible []Tile) ([]Advice, error) {
+	if err := CheckTileCounts(allTiles(hand)); err != nil {
+		return nil, err
+	}
+	concealed, fixed := splitHand(hand)
+
+	seen := map[Tile]int{}
+	for _, tile := range allTiles(hand) {
+		seen[tile]++
+	}
+	for _, tile := range visible {
+		seen[tile]++
+	}
+
+	discards := map[Tile]bool{}
+	advice := []Advice{}
+	for idx, discard := range concealed {
+		if discards[discard] || !discard.IsValid() || discard.IsBonus() {
+			continue
+		}
+		discards[discard] = true
+
+		rest := make([]Tile, 0, len(concealed))
+		rest = append(rest, concealed[:idx]...)
+		rest = append(rest, concealed[idx+1:]...)
+
+		current := Advice{
+			Discard:    discard,
+			Shanten:    ShantenTiles(rest, len(fixed)),
+			Acceptance: []Tile{},
+		}
+		for _, tile := range PlayingTiles() {
+			if ShantenTiles(append(rest, tile), len(fixed)) >= current.Shanten {
+				continue
+			}
+			current.Acceptance = append(current.Acceptance, tile)
+			if live := 4 - seen[tile]; live > 0 {
+				current.Live += live
+			}
+		}
+		advice = append(advice, current)
+	}
+
+	sort.Slice(advice, func(i, j int) bool {
+		switch {
+		case advice[i].Shanten != advice[j].Shanten:
+			return advice[i].Shanten < advice[j].Shanten
+		case advice[i].Live != advice[j].Live:
+			return advice[i].Live > advice[j].Live
+		default:
+			return advice[i].Discard < advice[j].Discard
+		}
+	})
+	return advice, nil
+}
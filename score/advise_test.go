@@ -0,0 +1,83 @@
+package score
+
+import (
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+)
+
+type AdviseTestSuite struct{}
+
+var _ = check.Suite(&AdviseTestSuite{})
+
+func mustAdvise(c *check.C, hand *Hand, visible []Tile) []Advice {
+	advice, err := Advise(hand, visible)
+	assert.Nil(c, err)
+	return advice
+}
+
+func (s *AdviseTestSuite) TestAdvise(c *check.C) {
+	// Discarding the north wind leaves a two-sided wait on 1c and 4c.
+	hand := mustParseHand(c, "123b 456b 789b 11c 23c N")
+	advice := mustAdvise(c, hand, nil)
+	if !assert.Len(c, advice, 13) {
+		return
+	}
+	assert.Equal(c, Advice{
+		Discard:    WindNorth,
+		Shanten:    0,
+		Acceptance: []Tile{Chars1, Chars4},
+		Live:       2 + 4,
+	}, advice[0])
+
+	// Discarding a 1c leaves a ready hand too, waiting on the single north wind.
+	assert.Equal(c, Advice{
+		Discard:    Chars1,
+		Shanten:    0,
+		Acceptance: []Tile{WindNorth},
+		Live:       3,
+	}, advice[1])
+
+	// Every other discard breaks up the ready hand.
+	for _, other := range advice[2:] {
+		assert.Equal(c, 1, other.Shanten, "discarding %v", other.Discard)
+	}
+
+	// Visible tiles are no longer live.
+	advice = mustAdvise(c, hand, mustParseTiles(c, "4c 1c"))
+	assert.Equal(c, WindNorth, advice[0].Discard)
+	assert.Equal(c, 1+3, advice[0].Live)
+}
+
+func (s *AdviseTestSuite) TestAdviseLiveTiles(c *check.C) {
+	// Both discards leave the hand ready, but one wait has fewer live tiles.
+	hand := mustParseHand(c, "123b 456b 789b 555c 1s 9s")
+	advice := mustAdvise(c, hand, mustParseTiles(c, "99s"))
+	if !assert.True(c, len(advice) > 2) {
+		return
+	}
+	assert.Equal(c, Bamboo9, advice[0].Discard)
+	assert.Equal(c, []Tile{Bamboo1}, advice[0].Acceptance)
+	assert.Equal(c, 3, advice[0].Live)
+	assert.Equal(c, Bamboo1, advice[1].Discard)
+	assert.Equal(c, []Tile{Bamboo9}, advice[1].Acceptance)
+	assert.Equal(c, 1, advice[1].Live)
+	assert.Equal(c, 1, advice[2].Shanten)
+}
+
+func (s *AdviseTestSuite) TestAdviseMelded(c *check.C) {
+	// Melded sets are never discarded.
+	hand := mustParseHand(c, "11c 23c E | 123b 456b 789b")
+	advice := mustAdvise(c, hand, nil)
+	discards := []Tile{}
+	for _, each := range advice {
+		discards = append(discards, each.Discard)
+	}
+	assert.Equal(c, []Tile{WindEast, Chars1, Chars2, Chars3}, discards)
+}
+
+func (s *AdviseTestSuite) TestAdviseTooManyTiles(c *check.C) {
+	_, err := Advise(mustParseHand(c, "22222b 234c 567c 789s 11s"), nil)
+	if assert.NotNil(c, err) {
+		assert.Contains(c, err.Error(), ErrTooManyTiles.Error())
+	}
+}
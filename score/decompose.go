@@ -0,0 +1,183 @@
+/*
+ * Decomposition of a flat list of tiles into sets.
+ */
+
+package score
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Standard errors.
+var (
+	ErrNoDecomposition = errors.New("tiles cannot be split up into a winning hand")
+)
+
+// Interpretation is one way of splitting up tiles into sets, together with its score.
+type Interpretation struct {
+	Hand  Hand `json:"hand"`
+	Score int  `json:"score"`
+}
+
+// decomposer searches for all ways to split up a multiset of tiles into sets.
+type decomposer struct {
+	counts   [flowerBase]int
+	sets     []Set
+	pillows  int
+	needSets int
+	results  [][]Set
+	seen     map[string]bool
+}
+
+func (d *decomposer) take(tiles ...Tile) {
+	for _, tile := range tiles {
+		d.counts[tile]--
+	}
+	d.sets = append(d.sets, Set{Tiles: tiles, Concealed: true})
+}
+
+func (d *decomposer) putBack(tiles ...Tile) {
+	for _, tile := range tiles {
+		d.counts[tile]++
+	}
+	d.sets = d.sets[:len(d.sets)-1]
+}
+
+// record stores the current sets as a result, unless the same sets were
+// already found in a different order.
+func (d *decomposer) record() {
+	found := copySets(d.sets)
+	sort.Sort(SortSetsByTileOrder(found))
+
+	keys := make([]string, len(found))
+	for idx := range found {
+		keys[idx] = FormatTiles(found[idx].Tiles)
+	}
+	key := strings.Join(keys, " ")
+	if d.seen[key] {
+		return
+	}
+
+	d.seen[key] = true
+	d.results = append(d.results, found)
+}
+
+// search assigns the lowest remaining tile to every possible set, and recurses.
+func (d *decomposer) search(from Tile) {
+	tile := from
+	for int(tile) < len(d.counts) && d.counts[tile] == 0 {
+		tile++
+	}
+
+	if int(tile) == len(d.counts) {
+		if d.pillows == 1 && d.needSets == 0 {
+			d.record()
+		}
+		return
+	}
+
+	try := func(isPillow bool, tiles ...Tile) {
+		if isPillow {
+			d.pillows++
+		} else {
+			d.needSets--
+		}
+		d.take(tiles...)
+		d.search(tile)
+		d.putBack(tiles...)
+		if isPillow {
+			d.pillows--
+		} else {
+			d.needSets++
+		}
+	}
+
+	count := d.counts[tile]
+	if count >= 2 && d.pillows == 0 {
+		try(true, tile, tile)
+	}
+	if d.needSets == 0 {
+		return
+	}
+	if count >= 3 {
+		try(false, tile, tile, tile)
+	}
+	if count == 4 {
+		try(false, tile, tile, tile, tile)
+	}
+	if number := tile.Number(); number >= 1 && number <= 7 && d.counts[tile+1] > 0 && d.counts[tile+2] > 0 {
+		try(false, tile, tile+1, tile+2)
+	}
+}
+
+// Decompose enumerates every way to split up the concealed tiles into sets,
+// such that together with the melded sets they form four sets and a pillow.
+// The returned sets only contain the concealed tiles; the melded sets are not included.
+func Decompose(concealed []Tile, melded []Set) [][]Set {
+	d := decomposer{
+		needSets: 4 - len(melded),
+		seen:     map[string]bool{},
+	}
+	if d.needSets < 0 {
+		return nil
+	}
+
+	for _, tile := range concealed {
+		// Bonus tiles are never part of a set.
+		if !tile.IsValid() || int(tile) >= len(d.counts) {
+			return nil
+		}
+		d.counts[tile]++
+	}
+
+	d.search(ballsBase)
+	return d.results
+}
+
+// copySets returns a deep copy of the sets, so that scoring them (which
+// sorts their tiles) doesn't modify the originals.
+func copySets(sets []Set) []Set {
+	copied := make([]Set, len(sets))
+	for idx := range sets {
+		copied[idx] = sets[idx]
+		copied[idx].Tiles = append([]Tile{}, sets[idx].Tiles...)
+	}
+	return copied
+}
+
+// Interpret scores every decomposition of the concealed tiles and melded sets,
+// using the default rule set. See RuleSet.Interpret().
+func Interpret(concealed []Tile, melded []Set, conditions Hand) ([]Interpretation, error) {
+	return DefaultRuleSet().Interpret(concealed, melded, conditions)
+}
+
+// Interpret scores every decomposition of the concealed tiles and melded sets.
+// The winds and win conditions are taken from the given hand; its sets are ignored.
+// The interpretations are returned highest-scoring first.
+func (rules *RuleSet) Interpret(concealed []Tile, melded []Set, conditions Hand) ([]Interpretation, error) {
+	decompositions := Decompose(concealed, melded)
+	if len(decompositions) == 0 && len(melded) == 0 && isThirteenOrphans(concealed) {
+		// The thirteen orphans don't form sets, so they are kept together.
+		decompositions = [][]Set{[]Set{Set{Tiles: concealed, Concealed: true}}}
+	}
+	if len(decompositions) == 0 {
+		return nil, ErrNoDecomposition
+	}
+
+	interpretations := make([]Interpretation, 0, len(decompositions))
+	for _, sets := range decompositions {
+		hand := conditions
+		hand.Sets = append(copySets(melded), sets...)
+		interpretations = append(interpretations, Interpretation{
+			Score: rules.Score(&hand),
+			Hand:  hand,
+		})
+	}
+
+	sort.SliceStable(interpretations, func(i, j int) bool {
+		return interpretations[i].Score > interpretations[j].Score
+	})
+	return interpretations, nil
+}
@@ -0,0 +1,90 @@
+package score
+
+import (
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+)
+
+type DecomposeTestSuite struct{}
+
+var _ = check.Suite(&DecomposeTestSuite{})
+
+func mustParseTiles(c *check.C, notation string) []Tile {
+	tiles, err := ParseTiles(notation)
+	if err != nil {
+		c.Fatal(err)
+	}
+	return tiles
+}
+
+func (s *DecomposeTestSuite) TestDecompose(c *check.C) {
+	// Three identical chows can also be read as three pungs.
+	decompositions := Decompose(mustParseTiles(c, "111222333b 456c 99s"), nil)
+	assert.Equal(c, [][]Set{
+		[]Set{
+			Set{Tiles: []Tile{Balls1, Balls1, Balls1}, Concealed: true},
+			Set{Tiles: []Tile{Balls2, Balls2, Balls2}, Concealed: true},
+			Set{Tiles: []Tile{Balls3, Balls3, Balls3}, Concealed: true},
+			Set{Tiles: []Tile{Chars4, Chars5, Chars6}, Concealed: true},
+			Set{Tiles: []Tile{Bamboo9, Bamboo9}, Concealed: true},
+		},
+		[]Set{
+			Set{Tiles: []Tile{Balls1, Balls2, Balls3}, Concealed: true},
+			Set{Tiles: []Tile{Balls1, Balls2, Balls3}, Concealed: true},
+			Set{Tiles: []Tile{Balls1, Balls2, Balls3}, Concealed: true},
+			Set{Tiles: []Tile{Chars4, Chars5, Chars6}, Concealed: true},
+			Set{Tiles: []Tile{Bamboo9, Bamboo9}, Concealed: true},
+		},
+	}, decompositions)
+
+	// The pillow can be taken from different places.
+	decompositions = Decompose(mustParseTiles(c, "11123b"), []Set{
+		Set{Tiles: []Tile{WindEast, WindEast, WindEast}},
+		Set{Tiles: []Tile{WindWest, WindWest, WindWest}},
+		Set{Tiles: []Tile{DragonRed, DragonRed, DragonRed}},
+	})
+	assert.Len(c, decompositions, 1)
+
+	decompositions = Decompose(mustParseTiles(c, "11123444b"), []Set{
+		Set{Tiles: []Tile{WindEast, WindEast, WindEast}},
+		Set{Tiles: []Tile{WindWest, WindWest, WindWest}},
+	})
+	assert.Len(c, decompositions, 2)
+
+	// A concealed kong.
+	decompositions = Decompose(mustParseTiles(c, "1111b 234c 567c 789s 99s"), nil)
+	assert.Len(c, decompositions, 1)
+
+	// No decomposition possible.
+	assert.Empty(c, Decompose(mustParseTiles(c, "1b2c3s"), nil))
+	assert.Empty(c, Decompose(mustParseTiles(c, "111b 222b 333b 444b 1f"), nil))
+	assert.Empty(c, Decompose(mustParseTiles(c, "11b"), make([]Set, 5)))
+}
+
+func (s *DecomposeTestSuite) TestInterpret(c *check.C) {
+	conditions := Hand{WindOwn: windOwn, WindRound: windRound}
+	interpretations, err := Interpret(mustParseTiles(c, "111222333b 456c 99s"), nil, conditions)
+	assert.Nil(c, err)
+	if !assert.Len(c, interpretations, 2) {
+		return
+	}
+
+	// Three concealed pungs score better than the chow hand.
+	assert.Equal(c, (8+4+4+20)*2, interpretations[0].Score)
+	assert.Equal(c, Pung, interpretations[0].Hand.Sets[1].setType)
+	assert.Equal(c, (0+20)*2, interpretations[1].Score)
+	assert.Equal(c, Chow, interpretations[1].Hand.Sets[1].setType)
+	assert.True(c, interpretations[0].Hand.Winning)
+	assert.Equal(c, windOwn, interpretations[0].Hand.WindOwn)
+
+	// The melded sets are part of each interpretation, but not modified.
+	melded := []Set{Set{Tiles: []Tile{Chars3, Chars1, Chars2}}}
+	interpretations, err = Interpret(mustParseTiles(c, "111222333b 99s"), melded, conditions)
+	assert.Nil(c, err)
+	assert.Len(c, interpretations, 2)
+	assert.Equal(c, []Tile{Chars3, Chars1, Chars2}, melded[0].Tiles)
+	assert.Len(c, interpretations[0].Hand.Sets, 5)
+
+	_, err = Interpret(mustParseTiles(c, "1b2c3s"), nil, conditions)
+	assert.Equal(c, ErrNoDecomposition, err)
+}
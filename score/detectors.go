@@ -4,9 +4,16 @@
 
 package score
 
+// Doubles for flowers and seasons.
+const (
+	ownBonusTileDoubles  = 1
+	allBonusTilesDoubles = 2
+)
+
 // A Detector returns the number of doubles detected.
 type Detector func(hand *Hand, simpleScore int) int
 
+// detectors are the hand-wide doubles of the default rule set.
 var detectors = map[string]Detector{
 	"pure straight":         pureStraight,
 	"all pungs":             allPungs,
@@ -17,38 +24,44 @@ var detectors = map[string]Detector{
 	"all terminals/honours": allTerminalsHonours,
 	"half-flush":            halfFlush,
 	"outside hand":          outsideHand,
+	"own flower":            ownFlower,
+	"own season":            ownSeason,
+	"all flowers":           allFlowers,
+	"all seasons":           allSeasons,
+	"replacement tile":      winOnReplacementTile,
+	"last tile of wall":     lastTileOfWall,
+	"robbed the kong":       robbedTheKong,
+	"out in draw":           outInDraw,
 }
 
-func findSetsOfType(hand *Hand, setType SetType) chan *Set {
-	ch := make(chan *Set)
-
-	go func() {
-		for idx := range hand.Sets {
-			set := &hand.Sets[idx]
-			if set.setType&setType > 0 {
-				ch <- set
-			}
-		}
-		close(ch)
-	}()
+// A PointsDetector returns the number of points detected.
+type PointsDetector func(hand *Hand) int
 
-	return ch
+// pointsDetectors are the hand-wide points of the default rule set.
+var pointsDetectors = map[string]PointsDetector{
+	"self-drawn":  selfDrawn,
+	"last chance": lastChance,
 }
 
-func allTiles(hand *Hand) chan Tile {
-	ch := make(chan Tile)
-
-	go func() {
-		for idx := range hand.Sets {
-			set := &hand.Sets[idx]
-			for _, tile := range set.Tiles {
-				ch <- tile
-			}
+// findSetsOfType returns the sets of the hand whose type is one of the given types.
+func findSetsOfType(hand *Hand, setType SetType) []*Set {
+	sets := []*Set{}
+	for idx := range hand.Sets {
+		set := &hand.Sets[idx]
+		if set.setType&setType > 0 {
+			sets = append(sets, set)
 		}
-		close(ch)
-	}()
+	}
+	return sets
+}
 
-	return ch
+// allTiles returns the tiles of all sets of the hand.
+func allTiles(hand *Hand) []Tile {
+	tiles := []Tile{}
+	for idx := range hand.Sets {
+		tiles = append(tiles, hand.Sets[idx].Tiles...)
+	}
+	return tiles
 }
 
 func pureStraight(hand *Hand, simpleScore int) int {
@@ -56,7 +69,7 @@ func pureStraight(hand *Hand, simpleScore int) int {
 	nrOfChows := 0
 	suit := NoTile
 
-	for chow := range findSetsOfType(hand, Chow) {
+	for _, chow := range findSetsOfType(hand, Chow) {
 		switch {
 		case suit == NoTile:
 			suit = chow.Tiles[0].Suit()
@@ -82,12 +95,7 @@ func allPungs(hand *Hand, simpleScore int) int {
 		return 0
 	}
 
-	count := 0
-	for _ = range findSetsOfType(hand, Pung+Kong) {
-		count++
-	}
-
-	if count == 4 {
+	if len(findSetsOfType(hand, Pung+Kong)) == 4 {
 		return 1
 	}
 
@@ -105,7 +113,7 @@ func fullFlush(hand *Hand, simpleScore int) int {
 	}
 
 	// Check that every tile is of the same suit
-	for tile := range allTiles(hand) {
+	for _, tile := range allTiles(hand) {
 		if tile.Suit() != suit {
 			return 0
 		}
@@ -116,7 +124,7 @@ func fullFlush(hand *Hand, simpleScore int) int {
 
 func threeConcealedPungs(hand *Hand, simpleScore int) int {
 	count := 0
-	for set := range findSetsOfType(hand, Pung+Kong) {
+	for _, set := range findSetsOfType(hand, Pung+Kong) {
 		if set.Concealed {
 			count++
 		}
@@ -134,12 +142,7 @@ func chowHand(hand *Hand, simpleScore int) int {
 		return 0
 	}
 
-	count := 0
-	for _ = range findSetsOfType(hand, Chow) {
-		count++
-	}
-
-	if count == 4 {
+	if len(findSetsOfType(hand, Chow)) == 4 {
 		return 1
 	}
 
@@ -148,7 +151,7 @@ func chowHand(hand *Hand, simpleScore int) int {
 
 func allSimples(hand *Hand, simpleScore int) int {
 	count := 0
-	for tile := range allTiles(hand) {
+	for _, tile := range allTiles(hand) {
 		count++
 		if !tile.IsSimple() {
 			return 0
@@ -164,7 +167,7 @@ func allSimples(hand *Hand, simpleScore int) int {
 
 func allTerminalsHonours(hand *Hand, simpleScore int) int {
 	count := 0
-	for tile := range allTiles(hand) {
+	for _, tile := range allTiles(hand) {
 		count++
 		if !tile.IsTerminal() && !tile.IsHonour() {
 			return 0
@@ -183,7 +186,7 @@ func halfFlush(hand *Hand, simpleScore int) int {
 	seenHonour := false
 
 	count := 0
-	for tile := range allTiles(hand) {
+	for _, tile := range allTiles(hand) {
 		count++
 
 		switch {
@@ -231,3 +234,95 @@ func outsideHand(hand *Hand, simpleScore int) int {
 
 	return 1
 }
+
+// hasBonusTile returns true when the hand contains the given flower or season.
+func hasBonusTile(hand *Hand, bonusTile Tile) bool {
+	for _, tile := range hand.Bonus {
+		if tile == bonusTile {
+			return true
+		}
+	}
+	return false
+}
+
+// ownBonusTile returns the flower or season belonging to the player's own wind.
+func ownBonusTile(hand *Hand, base Tile) Tile {
+	if !hand.WindOwn.IsWind() {
+		return NoTile
+	}
+	return base + hand.WindOwn - windBase
+}
+
+func ownFlower(hand *Hand, simpleScore int) int {
+	if hasBonusTile(hand, ownBonusTile(hand, flowerBase)) {
+		return ownBonusTileDoubles
+	}
+	return 0
+}
+
+func ownSeason(hand *Hand, simpleScore int) int {
+	if hasBonusTile(hand, ownBonusTile(hand, seasonBase)) {
+		return ownBonusTileDoubles
+	}
+	return 0
+}
+
+func allFlowers(hand *Hand, simpleScore int) int {
+	for tile := Flower1; tile <= Flower4; tile++ {
+		if !hasBonusTile(hand, tile) {
+			return 0
+		}
+	}
+	return allBonusTilesDoubles
+}
+
+func allSeasons(hand *Hand, simpleScore int) int {
+	for tile := Season1; tile <= Season4; tile++ {
+		if !hasBonusTile(hand, tile) {
+			return 0
+		}
+	}
+	return allBonusTilesDoubles
+}
+
+func winOnReplacementTile(hand *Hand, simpleScore int) int {
+	if hand.Winning && hand.WinOnReplacementTile {
+		return 1
+	}
+	return 0
+}
+
+func lastTileOfWall(hand *Hand, simpleScore int) int {
+	if hand.Winning && hand.LastTileOfWall {
+		return 1
+	}
+	return 0
+}
+
+func robbedTheKong(hand *Hand, simpleScore int) int {
+	if hand.Winning && hand.RobbedTheKong {
+		return 1
+	}
+	return 0
+}
+
+func outInDraw(hand *Hand, simpleScore int) int {
+	if hand.Winning && hand.OutInDraw {
+		return 1
+	}
+	return 0
+}
+
+func selfDrawn(hand *Hand) int {
+	if hand.Winning && hand.WinSelfDrawn {
+		return 2
+	}
+	return 0
+}
+
+func lastChance(hand *Hand) int {
+	if hand.Winning && hand.LastChance {
+		return 2
+	}
+	return 0
+}
@@ -3,6 +3,7 @@ package score
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 )
 
 // Tile represents a single MJ tile
@@ -94,12 +95,12 @@ func (tile Tile) IsValid() bool {
 		return 1 <= modulo && modulo <= 9
 	}
 
-	return tile.IsHonour() || tile.IsFlower() || tile.IsSeason()
+	return tile.IsHonour() || tile.IsBonus()
 }
 
-// MarshalJSON converts a tile to JSON.
+// MarshalJSON converts a tile to JSON. NoTile is allowed, as hands may be without winds.
 func (tile Tile) MarshalJSON() ([]byte, error) {
-	if !tile.IsValid() {
+	if !tile.IsValid() && tile != NoTile {
 		return []byte{}, ErrTileNotValid
 	}
 	return json.Marshal(int(tile))
@@ -112,7 +113,7 @@ func (tile *Tile) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	*tile = Tile(tilenr)
-	if !tile.IsValid() {
+	if !tile.IsValid() && *tile != NoTile {
 		return ErrTileNotValid
 	}
 	return nil
@@ -138,6 +139,11 @@ func (tile Tile) IsSeason() bool {
 	return tile > seasonBase && tile-seasonBase <= 4
 }
 
+// IsBonus returns true for flower and season tiles.
+func (tile Tile) IsBonus() bool {
+	return tile.IsFlower() || tile.IsSeason()
+}
+
 // IsHonour returns true for honour tiles.
 func (tile Tile) IsHonour() bool {
 	return tile.IsDragon() || tile.IsWind()
@@ -223,6 +229,42 @@ const (
 	Kong   SetType = 8
 )
 
+var setTypeNames = map[SetType]string{
+	NoSet:  "none",
+	Pillow: "pillow",
+	Chow:   "chow",
+	Pung:   "pung",
+	Kong:   "kong",
+}
+
+func (setType SetType) String() string {
+	name, found := setTypeNames[setType]
+	if !found {
+		return fmt.Sprintf("SetType(%d)", int(setType))
+	}
+	return name
+}
+
+// MarshalJSON converts a set type to its name in JSON.
+func (setType SetType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(setType.String())
+}
+
+// UnmarshalJSON reads a set type from its name in JSON.
+func (setType *SetType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	for candidate, candidateName := range setTypeNames {
+		if candidateName == name {
+			*setType = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown set type %q", name)
+}
+
 // Set consists of one to four tiles.
 type Set struct {
 	Tiles     []Tile `json:"tiles"`
@@ -241,15 +283,30 @@ func (set *Set) HasTerminalOrHonour() bool {
 }
 
 // Hand represents a hand (which may be non-winning) and consistst of sets and win conditions.
+// Flowers and seasons are not part of any set, and are kept separately as bonus tiles.
 type Hand struct {
-	Sets                 []Set `json:"sets"`
-	WindOwn              Tile  `json:"wind_own"`
-	WindRound            Tile  `json:"wind_round"`
-	LastChance           bool  `json:"last_chance"`
-	WinSelfDrawn         bool  `json:"win_self_drawn"`
-	WinOnReplacementTile bool  `json:"win_on_replacement_tile"`
-	LastTileOfWall       bool  `json:"last_tile_of_wall"`
-	RobbedTheKong        bool  `json:"robbed_the_kong"`
-	OutInDraw            bool  `json:"out_in_draw"`
-	Winning              bool  `json:"winning"`
+	Sets                 []Set  `json:"sets"`
+	Bonus                []Tile `json:"bonus"`
+	WindOwn              Tile   `json:"wind_own"`
+	WindRound            Tile   `json:"wind_round"`
+	LastChance           bool   `json:"last_chance"`             // Won on the only tile that could complete the hand.
+	WinSelfDrawn         bool   `json:"win_self_drawn"`          // Won on a tile from the wall.
+	WinOnReplacementTile bool   `json:"win_on_replacement_tile"` // Won on the tile drawn after a kong or bonus tile.
+	LastTileOfWall       bool   `json:"last_tile_of_wall"`       // Won on the last tile of the wall.
+	RobbedTheKong        bool   `json:"robbed_the_kong"`         // Won on a tile another player added to a pung.
+	OutInDraw            bool   `json:"out_in_draw"`             // Won in the first go-around, before any claims.
+	WinOnDeal            bool   `json:"win_on_deal"`             // Won on the deal (east) or on the first tile (others).
+	Winning              bool   `json:"winning"`
+}
+
+// PlayingTiles returns one of each kind of tile that can be part of a set,
+// so all tiles except the flowers and seasons.
+func PlayingTiles() []Tile {
+	tiles := make([]Tile, 0, 34)
+	for tile := ballsBase; tile < flowerBase; tile++ {
+		if tile.IsValid() {
+			tiles = append(tiles, tile)
+		}
+	}
+	return tiles
 }
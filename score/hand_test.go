@@ -28,6 +28,15 @@ func (s *HandTestSuite) TestTileJSON(c *check.C) {
 	err = json.Unmarshal(asJSON, &loadedTile)
 	assert.Nil(c, err)
 	assert.Equal(c, Balls4, loadedTile)
+
+	// Hands without winds can be sent back and forth too.
+	hand := Hand{Sets: []Set{{Tiles: []Tile{Balls4, Balls4}}}}
+	asJSON, err = json.Marshal(hand)
+	assert.Nil(c, err)
+	var loadedHand Hand
+	err = json.Unmarshal(asJSON, &loadedHand)
+	assert.Nil(c, err)
+	assert.Equal(c, NoTile, loadedHand.WindOwn)
 }
 
 func (s *HandTestSuite) TestSetJSON(c *check.C) {
@@ -76,3 +85,17 @@ func (s *HandTestSuite) TestSetJSON(c *check.C) {
 	err := json.Unmarshal([]byte("{\"tiles\":[1,2],\"concealed\":false}"), &loadedSet)
 	assert.Equal(c, ErrTileNotValid, err)
 }
+
+func (s *HandTestSuite) TestSetTypeJSON(c *check.C) {
+	for setType := range setTypeNames {
+		asJSON, err := json.Marshal(setType)
+		assert.Nil(c, err)
+
+		var loaded SetType
+		assert.Nil(c, json.Unmarshal(asJSON, &loaded))
+		assert.Equal(c, setType, loaded)
+	}
+
+	var loaded SetType
+	assert.NotNil(c, json.Unmarshal([]byte(`"triplet"`), &loaded))
+}
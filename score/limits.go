@@ -0,0 +1,171 @@
+/*
+ * Detectors for limit hands, which score the rule set's limit instead of
+ * the normal computation.
+ *
+ * Limit hands look at the tiles of the hand rather than only its sets, so
+ * that hands like the thirteen orphans can be expressed as well.
+ */
+
+package score
+
+// A LimitDetector returns true when the hand is a limit hand.
+type LimitDetector func(hand *Hand) bool
+
+// limitHands are the limit hands of the default rule set.
+var limitHands = map[string]LimitDetector{
+	"thirteen orphans":     thirteenOrphans,
+	"nine gates":           nineGates,
+	"all honours":          allHonours,
+	"all kongs":            allKongs,
+	"four concealed pungs": fourConcealedPungs,
+	"big three dragons":    bigThreeDragons,
+	"big four winds":       bigFourWinds,
+	"small four winds":     smallFourWinds,
+	"heavenly hand":        heavenlyHand,
+	"earthly hand":         earthlyHand,
+}
+
+// orphans are the terminals and honours, one of each of which forms the thirteen orphans.
+var orphans = []Tile{
+	Balls1, Balls9, Chars1, Chars9, Bamboo1, Bamboo9,
+	WindEast, WindSouth, WindWest, WindNorth,
+	DragonRed, DragonGreen, DragonWhite,
+}
+
+// countTiles returns how often each tile occurs in the hand's sets,
+// along with the total number of tiles.
+func countTiles(hand *Hand) (map[Tile]int, int) {
+	counts := map[Tile]int{}
+	total := 0
+	for _, tile := range allTiles(hand) {
+		counts[tile]++
+		total++
+	}
+	return counts, total
+}
+
+// countSetsOfType returns the number of sets of the given type whose first tile
+// passes the filter, and how many of those are concealed.
+func countSetsOfType(hand *Hand, setType SetType, filter func(tile Tile) bool) (int, int) {
+	count := 0
+	concealed := 0
+	for _, set := range findSetsOfType(hand, setType) {
+		if !filter(set.Tiles[0]) {
+			continue
+		}
+		count++
+		if set.Concealed {
+			concealed++
+		}
+	}
+	return count, concealed
+}
+
+func anyTile(tile Tile) bool { return true }
+
+// isThirteenOrphans returns true when the tiles are one of each orphan, plus one extra.
+func isThirteenOrphans(tiles []Tile) bool {
+	if len(tiles) != len(orphans)+1 {
+		return false
+	}
+
+	counts := map[Tile]int{}
+	for _, tile := range tiles {
+		counts[tile]++
+	}
+	for _, orphan := range orphans {
+		if counts[orphan] == 0 {
+			return false
+		}
+	}
+	return len(counts) == len(orphans)
+}
+
+func thirteenOrphans(hand *Hand) bool {
+	tiles := []Tile{}
+	for _, tile := range allTiles(hand) {
+		tiles = append(tiles, tile)
+	}
+	return isThirteenOrphans(tiles)
+}
+
+func nineGates(hand *Hand) bool {
+	for idx := range hand.Sets {
+		if !hand.Sets[idx].Concealed {
+			return false
+		}
+	}
+
+	counts, total := countTiles(hand)
+	if total != 14 || len(hand.Sets) == 0 || len(hand.Sets[0].Tiles) == 0 {
+		return false
+	}
+
+	suit := hand.Sets[0].Tiles[0].Suit()
+	if suit == NoTile {
+		return false
+	}
+	for tile, count := range counts {
+		if tile.Suit() != suit {
+			return false
+		}
+		switch tile.Number() {
+		case 1, 9:
+			if count < 3 {
+				return false
+			}
+		}
+	}
+	for number := Tile(1); number <= 9; number++ {
+		if counts[suit+number] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func allHonours(hand *Hand) bool {
+	if !hand.Winning {
+		return false
+	}
+	for _, tile := range allTiles(hand) {
+		if !tile.IsHonour() {
+			return false
+		}
+	}
+	return true
+}
+
+func allKongs(hand *Hand) bool {
+	kongs, _ := countSetsOfType(hand, Kong, anyTile)
+	return hand.Winning && kongs == 4
+}
+
+func fourConcealedPungs(hand *Hand) bool {
+	_, concealed := countSetsOfType(hand, Pung+Kong, anyTile)
+	return hand.Winning && concealed == 4
+}
+
+func bigThreeDragons(hand *Hand) bool {
+	dragons, _ := countSetsOfType(hand, Pung+Kong, Tile.IsDragon)
+	return hand.Winning && dragons == 3
+}
+
+func bigFourWinds(hand *Hand) bool {
+	winds, _ := countSetsOfType(hand, Pung+Kong, Tile.IsWind)
+	return hand.Winning && winds == 4
+}
+
+func smallFourWinds(hand *Hand) bool {
+	winds, _ := countSetsOfType(hand, Pung+Kong, Tile.IsWind)
+	windPillows, _ := countSetsOfType(hand, Pillow, Tile.IsWind)
+	return hand.Winning && winds == 3 && windPillows == 1
+}
+
+func heavenlyHand(hand *Hand) bool {
+	return hand.Winning && hand.WinOnDeal && hand.WindOwn == WindEast
+}
+
+func earthlyHand(hand *Hand) bool {
+	return hand.Winning && hand.WinOnDeal && hand.WindOwn != WindEast
+}
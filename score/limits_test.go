@@ -0,0 +1,66 @@
+package score
+
+import (
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+)
+
+type LimitsTestSuite struct{}
+
+var _ = check.Suite(&LimitsTestSuite{})
+
+func mustParseHand(c *check.C, notation string) *Hand {
+	hand, err := ParseHand(notation)
+	if err != nil {
+		c.Fatal(err)
+	}
+	return &hand
+}
+
+func (s *LimitsTestSuite) TestLimitHands(c *check.C) {
+	assertLimit := func(expectLimitHands []string, notation string) {
+		hand := mustParseHand(c, notation)
+		breakdown := ScoreBreakdown(hand)
+		assert.Equal(c, expectLimitHands, breakdown.LimitHands, notation)
+		if len(expectLimitHands) > 0 {
+			assert.Equal(c, DefaultRuleSet().Limit, breakdown.Score, notation)
+			assert.True(c, breakdown.Winning, notation)
+		}
+	}
+
+	assertLimit([]string{"thirteen orphans"}, "19b19c19sESWNrgwg @EE")
+	assertLimit([]string{"thirteen orphans"}, "19b 19c 19s ESWN rgw | 1s @EE")
+	assertLimit([]string{}, "19b19c19sESWNrgw @EE")
+	assertLimit([]string{}, "19b19c19sESWNrrw @EE")
+
+	assertLimit([]string{"nine gates"}, "111b 234b 567b 88b 999b")
+	assertLimit([]string{}, "111b 234b 567b 88b | 999b")
+	assertLimit([]string{}, "111b 234b 567b 88c 999b")
+
+	assertLimit([]string{"all honours"}, "| EEE SSS rrr ggg NN @WW")
+	assertLimit([]string{"all kongs"}, "| 1111b 2222c 5555s 9999s NN @WW")
+	assertLimit([]string{"four concealed pungs"}, "111b 222c 555s 999s | NN @WW")
+	assertLimit([]string{"big three dragons"}, "| rrr ggg wwww 123b 99s @WW")
+	assertLimit([]string{"all honours", "big four winds"}, "| EEE SSS WWW NNN rr @WW")
+	assertLimit([]string{"all honours", "small four winds"}, "| EEE SSS WWW NN rrr @WW")
+
+	// Almost, but not quite.
+	assertLimit([]string{}, "| rrr ggg ww 123b 999s @WW")
+	assertLimit([]string{}, "| EEE SSS WW 123b 999s @WW")
+
+	hand := mustParseHand(c, "| 123b 456b 789b 222s 99s @EE")
+	hand.WinOnDeal = true
+	assert.Equal(c, []string{"heavenly hand"}, ScoreBreakdown(hand).LimitHands)
+	hand.WindOwn = WindSouth
+	assert.Equal(c, []string{"earthly hand"}, ScoreBreakdown(hand).LimitHands)
+	hand.Sets = hand.Sets[1:]
+	assert.Equal(c, []string{}, ScoreBreakdown(hand).LimitHands)
+}
+
+func (s *LimitsTestSuite) TestInterpretThirteenOrphans(c *check.C) {
+	interpretations, err := Interpret(mustParseTiles(c, "19b19c19sESWNrgwN"), nil, Hand{})
+	assert.Nil(c, err)
+	if assert.Len(c, interpretations, 1) {
+		assert.Equal(c, DefaultRuleSet().Limit, interpretations[0].Score)
+	}
+}
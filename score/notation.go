@@ -0,0 +1,313 @@
+/*
+ * Compact string notation for tiles and hands.
+ *
+ * A hand is written as whitespace-separated groups of tiles, each group
+ * forming one set. Suited tiles are written as their numbers followed by
+ * the suit, honours as one letter per tile:
+ *
+ *   b  balls          E S W N  winds
+ *   c  characters     r g w    red, green, white dragon
+ *   s  bamboo
+ *   f  flowers
+ *   t  seasons
+ *
+ * Sets left of a '|' are concealed, sets right of it are melded. Groups of
+ * flowers and seasons become the hand's bonus tiles, wherever they are. The
+ * hand may end with '@' followed by the player's own wind and the round wind.
+ *
+ * Example: "123b 555c EEE rr | 99s 13f2t @NW"
+ */
+
+package score
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	notationMeldMarker  = '|'
+	notationWindsMarker = '@'
+)
+
+var notationSuits = map[rune]Tile{
+	'b': ballsBase,
+	'c': charsBase,
+	's': bambooBase,
+	'f': flowerBase,
+	't': seasonBase,
+}
+
+var notationHonours = map[rune]Tile{
+	'E': WindEast,
+	'S': WindSouth,
+	'W': WindWest,
+	'N': WindNorth,
+	'r': DragonRed,
+	'g': DragonGreen,
+	'w': DragonWhite,
+}
+
+// NotationError is returned when a hand notation cannot be parsed.
+// It points at the offending character.
+type NotationError struct {
+	Notation string
+	Pos      int // Byte offset of the offending character.
+	Reason   string
+}
+
+func (e *NotationError) Error() string {
+	if e.Pos >= len(e.Notation) {
+		return fmt.Sprintf("invalid notation %q: %s at end of input", e.Notation, e.Reason)
+	}
+	char, _ := utf8.DecodeRuneInString(e.Notation[e.Pos:])
+	return fmt.Sprintf("invalid notation %q: %s at position %d (%q)",
+		e.Notation, e.Reason, e.Pos+1, char)
+}
+
+// notationParser keeps track of the position in the notation string.
+type notationParser struct {
+	notation string
+	pos      int
+}
+
+func (p *notationParser) fail(pos int, format string, args ...interface{}) error {
+	return &NotationError{p.notation, pos, fmt.Sprintf(format, args...)}
+}
+
+func (p *notationParser) atEnd() bool {
+	return p.pos >= len(p.notation)
+}
+
+func (p *notationParser) peek() rune {
+	return rune(p.notation[p.pos])
+}
+
+func (p *notationParser) skipSpace() {
+	for !p.atEnd() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+// parseGroup parses tiles until the next whitespace or marker.
+func (p *notationParser) parseGroup() ([]Tile, error) {
+	tiles := []Tile{}
+	numbers := []int{}
+	numbersStart := 0
+
+	for !p.atEnd() {
+		char := p.peek()
+		if unicode.IsSpace(char) || char == notationMeldMarker || char == notationWindsMarker {
+			break
+		}
+
+		switch {
+		case '0' <= char && char <= '9':
+			if len(numbers) == 0 {
+				numbersStart = p.pos
+			}
+			numbers = append(numbers, int(char-'0'))
+		case notationSuits[char] != NoTile:
+			if len(numbers) == 0 {
+				return nil, p.fail(p.pos, "suit without tile numbers")
+			}
+			for idx, number := range numbers {
+				tile := notationSuits[char] + Tile(number)
+				if !tile.IsValid() {
+					return nil, p.fail(numbersStart+idx, "no such tile %d%c", number, char)
+				}
+				tiles = append(tiles, tile)
+			}
+			numbers = numbers[:0]
+		case notationHonours[char] != NoTile:
+			if len(numbers) > 0 {
+				return nil, p.fail(p.pos, "tile numbers without suit")
+			}
+			tiles = append(tiles, notationHonours[char])
+		default:
+			return nil, p.fail(p.pos, "unknown tile")
+		}
+		p.pos++
+	}
+
+	if len(numbers) > 0 {
+		return nil, p.fail(p.pos, "tile numbers without suit")
+	}
+	return tiles, nil
+}
+
+// parseWinds parses the own and round wind following the winds marker.
+func (p *notationParser) parseWinds(hand *Hand) error {
+	winds := []*Tile{&hand.WindOwn, &hand.WindRound}
+	for _, wind := range winds {
+		if p.atEnd() {
+			return p.fail(p.pos, "expected a wind")
+		}
+		tile := notationHonours[p.peek()]
+		if !tile.IsWind() {
+			return p.fail(p.pos, "expected a wind")
+		}
+		*wind = tile
+		p.pos++
+	}
+
+	p.skipSpace()
+	if !p.atEnd() {
+		return p.fail(p.pos, "unexpected character after winds")
+	}
+	return nil
+}
+
+// ParseHand parses a hand from its compact notation.
+func ParseHand(notation string) (Hand, error) {
+	p := notationParser{notation: notation}
+	hand := Hand{Sets: []Set{}}
+	concealed := true
+
+	for {
+		p.skipSpace()
+		if p.atEnd() {
+			break
+		}
+
+		switch p.peek() {
+		case notationMeldMarker:
+			if !concealed {
+				return Hand{}, p.fail(p.pos, "more than one meld marker")
+			}
+			concealed = false
+			p.pos++
+			continue
+		case notationWindsMarker:
+			p.pos++
+			if err := p.parseWinds(&hand); err != nil {
+				return Hand{}, err
+			}
+			return hand, nil
+		}
+
+		groupStart := p.pos
+		tiles, err := p.parseGroup()
+		if err != nil {
+			return Hand{}, err
+		}
+
+		bonusTiles := 0
+		for _, tile := range tiles {
+			if tile.IsBonus() {
+				bonusTiles++
+			}
+		}
+		switch bonusTiles {
+		case 0:
+			hand.Sets = append(hand.Sets, Set{Tiles: tiles, Concealed: concealed})
+		case len(tiles):
+			hand.Bonus = append(hand.Bonus, tiles...)
+		default:
+			return Hand{}, p.fail(groupStart, "flowers and seasons mixed with other tiles")
+		}
+	}
+
+	return hand, nil
+}
+
+// ParseTiles parses a flat list of tiles from the compact notation,
+// ignoring how they are grouped.
+func ParseTiles(notation string) ([]Tile, error) {
+	p := notationParser{notation: notation}
+	tiles := []Tile{}
+
+	for {
+		p.skipSpace()
+		if p.atEnd() {
+			return tiles, nil
+		}
+
+		switch p.peek() {
+		case notationMeldMarker, notationWindsMarker:
+			return nil, p.fail(p.pos, "markers are not allowed in a list of tiles")
+		}
+
+		group, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		tiles = append(tiles, group...)
+	}
+}
+
+// notationSuit returns the suit letter of the tile, or 0 for honours.
+func notationSuit(tile Tile) rune {
+	switch {
+	case tile.IsFlower():
+		return 'f'
+	case tile.IsSeason():
+		return 't'
+	}
+
+	switch tile.Suit() {
+	case ballsBase:
+		return 'b'
+	case charsBase:
+		return 'c'
+	case bambooBase:
+		return 's'
+	}
+	return 0
+}
+
+// FormatTiles returns the compact notation of the given tiles as a single group.
+// Consecutive tiles of the same suit share the suit letter.
+func FormatTiles(tiles []Tile) string {
+	var builder strings.Builder
+
+	for idx, tile := range tiles {
+		suit := notationSuit(tile)
+		if suit == 0 {
+			for char, honour := range notationHonours {
+				if honour == tile {
+					builder.WriteRune(char)
+				}
+			}
+			continue
+		}
+
+		builder.WriteRune(rune('0' + int(tile)%10))
+		if idx == len(tiles)-1 || notationSuit(tiles[idx+1]) != suit {
+			builder.WriteRune(suit)
+		}
+	}
+
+	return builder.String()
+}
+
+// FormatHand returns the compact notation of the hand.
+// Concealed sets are written before the meld marker, melded sets after it.
+func FormatHand(hand *Hand) string {
+	concealed := []string{}
+	melded := []string{}
+	for idx := range hand.Sets {
+		set := &hand.Sets[idx]
+		if set.Concealed {
+			concealed = append(concealed, FormatTiles(set.Tiles))
+		} else {
+			melded = append(melded, FormatTiles(set.Tiles))
+		}
+	}
+
+	parts := concealed
+	if len(melded) > 0 {
+		parts = append(parts, string(notationMeldMarker))
+		parts = append(parts, melded...)
+	}
+	if len(hand.Bonus) > 0 {
+		parts = append(parts, FormatTiles(hand.Bonus))
+	}
+	if hand.WindOwn.IsWind() && hand.WindRound.IsWind() {
+		parts = append(parts, string(notationWindsMarker)+FormatTiles([]Tile{hand.WindOwn, hand.WindRound}))
+	}
+
+	return strings.Join(parts, " ")
+}
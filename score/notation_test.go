@@ -0,0 +1,122 @@
+package score
+
+import (
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+)
+
+type NotationTestSuite struct{}
+
+var _ = check.Suite(&NotationTestSuite{})
+
+func (s *NotationTestSuite) TestParseHand(c *check.C) {
+	hand, err := ParseHand("123b 555c EEE rr | 99s @NW")
+	assert.Nil(c, err)
+	assert.Equal(c, Hand{
+		Sets: []Set{
+			Set{Tiles: []Tile{Balls1, Balls2, Balls3}, Concealed: true},
+			Set{Tiles: []Tile{Chars5, Chars5, Chars5}, Concealed: true},
+			Set{Tiles: []Tile{WindEast, WindEast, WindEast}, Concealed: true},
+			Set{Tiles: []Tile{DragonRed, DragonRed}, Concealed: true},
+			Set{Tiles: []Tile{Bamboo9, Bamboo9}},
+		},
+		WindOwn:   WindNorth,
+		WindRound: WindWest,
+	}, hand)
+
+	hand, err = ParseHand("  | gggg  1b2c ")
+	assert.Nil(c, err)
+	assert.Equal(c, Hand{
+		Sets: []Set{
+			Set{Tiles: []Tile{DragonGreen, DragonGreen, DragonGreen, DragonGreen}},
+			Set{Tiles: []Tile{Balls1, Chars2}},
+		},
+	}, hand)
+
+	hand, err = ParseHand("11b 1f | 22b 3f4t")
+	assert.Nil(c, err)
+	assert.Equal(c, Hand{
+		Sets: []Set{
+			Set{Tiles: []Tile{Balls1, Balls1}, Concealed: true},
+			Set{Tiles: []Tile{Balls2, Balls2}},
+		},
+		Bonus: []Tile{Flower1, Flower3, Season4},
+	}, hand)
+
+	hand, err = ParseHand("")
+	assert.Nil(c, err)
+	assert.Equal(c, Hand{Sets: []Set{}}, hand)
+}
+
+func (s *NotationTestSuite) TestParseHandErrors(c *check.C) {
+	assertError := func(notation string, expectPos int, expectReason string) {
+		_, err := ParseHand(notation)
+		if !assert.IsType(c, &NotationError{}, err, notation) {
+			return
+		}
+		notationErr := err.(*NotationError)
+		assert.Equal(c, expectPos, notationErr.Pos, notation)
+		assert.Equal(c, expectReason, notationErr.Reason, notation)
+	}
+
+	assertError("123x", 3, "unknown tile")
+	assertError("123b 0c", 5, "no such tile 0c")
+	assertError("5f", 0, "no such tile 5f")
+	assertError("123", 3, "tile numbers without suit")
+	assertError("12E", 2, "tile numbers without suit")
+	assertError("b", 0, "suit without tile numbers")
+	assertError("11b | 22b | 33b", 10, "more than one meld marker")
+	assertError("11b 1f22b", 4, "flowers and seasons mixed with other tiles")
+	assertError("11b @N", 6, "expected a wind")
+	assertError("11b @Nr", 6, "expected a wind")
+	assertError("11b @NW 22b", 8, "unexpected character after winds")
+
+	_, err := ParseHand("123x")
+	assert.Equal(c, `invalid notation "123x": unknown tile at position 4 ('x')`, err.Error())
+}
+
+func (s *NotationTestSuite) TestParseTiles(c *check.C) {
+	tiles, err := ParseTiles("123b Ew 12f 4t")
+	assert.Nil(c, err)
+	assert.Equal(c, []Tile{Balls1, Balls2, Balls3, WindEast, DragonWhite,
+		Flower1, Flower2, Season4}, tiles)
+
+	_, err = ParseTiles("123b | 4c")
+	assert.NotNil(c, err)
+}
+
+func (s *NotationTestSuite) TestFormatTiles(c *check.C) {
+	assert.Equal(c, "123b", FormatTiles([]Tile{Balls1, Balls2, Balls3}))
+	assert.Equal(c, "1b2c3s", FormatTiles([]Tile{Balls1, Chars2, Bamboo3}))
+	assert.Equal(c, "ESWNrgw", FormatTiles([]Tile{WindEast, WindSouth, WindWest, WindNorth,
+		DragonRed, DragonGreen, DragonWhite}))
+	assert.Equal(c, "12f34t", FormatTiles([]Tile{Flower1, Flower2, Season3, Season4}))
+	assert.Equal(c, "", FormatTiles([]Tile{}))
+}
+
+func (s *NotationTestSuite) TestRoundTrip(c *check.C) {
+	assertRoundTrip := func(notation string) {
+		hand, err := ParseHand(notation)
+		assert.Nil(c, err, notation)
+		assert.Equal(c, notation, FormatHand(&hand))
+	}
+
+	assertRoundTrip("123b 555c EEE rr | 99s @NW")
+	assertRoundTrip("123b 555c EEE rr 99s")
+	assertRoundTrip("123b 555c EEE rr | 99s 13f2t @NW")
+	assertRoundTrip("123b 4f")
+	assertRoundTrip("| 1111c 789s @ES")
+	assertRoundTrip("1b2c 9s")
+	assertRoundTrip("")
+
+	hand := Hand{Sets: []Set{
+		Set{Tiles: []Tile{DragonGreen, DragonGreen, DragonGreen, DragonGreen}},
+		Set{Tiles: []Tile{Chars4, Chars4, Chars4}, Concealed: true},
+	}}
+	parsed, err := ParseHand(FormatHand(&hand))
+	assert.Nil(c, err)
+	assert.Equal(c, []Set{
+		Set{Tiles: []Tile{Chars4, Chars4, Chars4}, Concealed: true},
+		Set{Tiles: []Tile{DragonGreen, DragonGreen, DragonGreen, DragonGreen}},
+	}, parsed.Sets)
+}
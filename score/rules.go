@@ -0,0 +1,204 @@
+/*
+ * Rule sets bundle everything that differs between scoring variants.
+ */
+
+package score
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultRuleSetName is the name of the rule set used when none is specified.
+const DefaultRuleSetName = "default"
+
+// Standard errors.
+var (
+	ErrUnknownRuleSet   = errors.New("unknown rule set")
+	ErrRuleSetNameTaken = errors.New("rule set name already registered")
+)
+
+// SetPoints are the points and doubles awarded for pillows, pungs and kongs.
+type SetPoints struct {
+	SimplePung   int  `json:"simple_pung" yaml:"simple_pung"`     // Points for an exposed pung of simples.
+	TerminalPung int  `json:"terminal_pung" yaml:"terminal_pung"` // Points for an exposed pung of terminals.
+	HonourPung   int  `json:"honour_pung" yaml:"honour_pung"`     // Points for an exposed pung of winds or dragons.
+	Concealed    int  `json:"concealed" yaml:"concealed"`         // Multiplier for concealed pungs and kongs.
+	Kong         int  `json:"kong" yaml:"kong"`                   // Multiplier for kongs.
+	DragonPillow int  `json:"dragon_pillow" yaml:"dragon_pillow"` // Points for a pillow of dragons.
+	WindPillow   int  `json:"wind_pillow" yaml:"wind_pillow"`     // Points for a pillow of the own or round wind.
+	DragonPung   int  `json:"dragon_pung" yaml:"dragon_pung"`     // Doubles for a pung or kong of dragons.
+	WindPung     int  `json:"wind_pung" yaml:"wind_pung"`         // Doubles for a pung or kong of the own or round wind.
+	DoubleWind   bool `json:"double_wind" yaml:"double_wind"`     // Whether the own wind counts twice when it's also the round wind.
+}
+
+// DealerRules describe when the dealer stays East for another hand, rather than
+// passing the deal on to the next player.
+type DealerRules struct {
+	KeepsOnWin  bool `json:"keeps_on_win" yaml:"keeps_on_win"`   // The dealer stays after winning.
+	KeepsOnDraw bool `json:"keeps_on_draw" yaml:"keeps_on_draw"` // The dealer stays when nobody won.
+}
+
+// RuleSet bundles the set points, detectors, limit hands and bonuses of a scoring variant.
+type RuleSet struct {
+	Name            string                    `json:"name"`
+	Description     string                    `json:"description"`
+	Points          SetPoints                 `json:"points"`
+	WinningBonus    int                       `json:"winning_bonus"`
+	BonusTilePoints int                       `json:"bonus_tile_points"`
+	Limit           int                       `json:"limit"`            // Maximum score of a hand, and the score of limit hands. 0 means no limit.
+	LimitHandScore  int                       `json:"limit_hand_score"` // Score of limit hands when there is no limit.
+	Settlement      SettlementRules           `json:"settlement"`
+	Dealer          DealerRules               `json:"dealer"`
+	Detectors       map[string]Detector       `json:"-"`
+	PointsDetectors map[string]PointsDetector `json:"-"`
+	LimitHands      map[string]LimitDetector  `json:"-"`
+}
+
+// Copy returns a deep copy of the rule set under a new name, so that it can be
+// tweaked without influencing the original.
+func (rules *RuleSet) Copy(name string) *RuleSet {
+	copied := *rules
+	copied.Name = name
+
+	copied.Detectors = make(map[string]Detector, len(rules.Detectors))
+	for label, detector := range rules.Detectors {
+		copied.Detectors[label] = detector
+	}
+	copied.PointsDetectors = make(map[string]PointsDetector, len(rules.PointsDetectors))
+	for label, detector := range rules.PointsDetectors {
+		copied.PointsDetectors[label] = detector
+	}
+	copied.LimitHands = make(map[string]LimitDetector, len(rules.LimitHands))
+	for label, detector := range rules.LimitHands {
+		copied.LimitHands[label] = detector
+	}
+
+	return &copied
+}
+
+// WithLimit returns a copy of the rule set with a different limit, for tables that
+// play with their own limit. A limit of 0 means no limit.
+func (rules *RuleSet) WithLimit(limit int) *RuleSet {
+	copied := rules.Copy(rules.Name)
+	copied.Limit = limit
+	return copied
+}
+
+// fixedDoubles returns a detector that awards the given number of doubles
+// whenever the wrapped detector finds any.
+func fixedDoubles(detector Detector, doubles int) Detector {
+	return func(hand *Hand, simpleScore int) int {
+		if detector(hand, simpleScore) == 0 {
+			return 0
+		}
+		return doubles
+	}
+}
+
+var (
+	ruleSets      = map[string]*RuleSet{}
+	ruleSetsMutex sync.RWMutex
+)
+
+// RegisterRuleSet makes the rule set available under its name.
+func RegisterRuleSet(rules *RuleSet) error {
+	ruleSetsMutex.Lock()
+	defer ruleSetsMutex.Unlock()
+
+	if _, found := ruleSets[rules.Name]; found {
+		return fmt.Errorf("%q: %s", rules.Name, ErrRuleSetNameTaken)
+	}
+	ruleSets[rules.Name] = rules
+	return nil
+}
+
+// LookupRuleSet returns the rule set registered under the given name.
+func LookupRuleSet(name string) (*RuleSet, error) {
+	ruleSetsMutex.RLock()
+	defer ruleSetsMutex.RUnlock()
+
+	rules, found := ruleSets[name]
+	if !found {
+		return nil, fmt.Errorf("%q: %s", name, ErrUnknownRuleSet)
+	}
+	return rules, nil
+}
+
+// RuleSets returns all registered rule sets, sorted by name.
+func RuleSets() []*RuleSet {
+	ruleSetsMutex.RLock()
+	defer ruleSetsMutex.RUnlock()
+
+	all := make([]*RuleSet, 0, len(ruleSets))
+	for _, rules := range ruleSets {
+		all = append(all, rules)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// DefaultRuleSet returns the rule set registered under DefaultRuleSetName.
+func DefaultRuleSet() *RuleSet {
+	rules, err := LookupRuleSet(DefaultRuleSetName)
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+// defaultRuleSet contains our local rules.
+var defaultRuleSet = RuleSet{
+	Name:        DefaultRuleSetName,
+	Description: "Our local rules",
+	Points: SetPoints{
+		SimplePung:   2,
+		TerminalPung: 4,
+		HonourPung:   4,
+		Concealed:    2,
+		Kong:         4,
+		DragonPillow: 2,
+		WindPillow:   2,
+		DragonPung:   1,
+		WindPung:     1,
+	},
+	WinningBonus:    20,
+	BonusTilePoints: 4,
+	Limit:           1000,
+	LimitHandScore:  1000,
+	Settlement: SettlementRules{
+		LosersPayEachOther: true,
+	},
+	Dealer: DealerRules{
+		KeepsOnWin:  true,
+		KeepsOnDraw: true,
+	},
+	Detectors:       detectors,
+	PointsDetectors: pointsDetectors,
+	LimitHands:      limitHands,
+}
+
+// classicalRuleSet returns the Chinese Classical variant, which differs from
+// our local rules in its doubles.
+func classicalRuleSet() *RuleSet {
+	rules := defaultRuleSet.Copy("classical")
+	rules.Description = "Chinese Classical: winds count double, fewer hand-wide doubles"
+	rules.Points.DoubleWind = true
+	rules.Dealer.KeepsOnDraw = false
+	rules.Detectors["full flush"] = fixedDoubles(fullFlush, 3)
+	delete(rules.Detectors, "pure straight")
+	delete(rules.Detectors, "all simples")
+	delete(rules.Detectors, "outside hand")
+	delete(rules.LimitHands, "small four winds")
+	return rules
+}
+
+func init() {
+	for _, rules := range []*RuleSet{&defaultRuleSet, classicalRuleSet()} {
+		if err := RegisterRuleSet(rules); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package score
+
+import (
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+)
+
+type RulesTestSuite struct{}
+
+var _ = check.Suite(&RulesTestSuite{})
+
+func (s *RulesTestSuite) TestRegistry(c *check.C) {
+	rules, err := LookupRuleSet(DefaultRuleSetName)
+	assert.Nil(c, err)
+	assert.Equal(c, DefaultRuleSet(), rules)
+
+	_, err = LookupRuleSet("nonexistent")
+	assert.Contains(c, err.Error(), ErrUnknownRuleSet.Error())
+
+	err = RegisterRuleSet(DefaultRuleSet().Copy("classical"))
+	assert.Contains(c, err.Error(), ErrRuleSetNameTaken.Error())
+
+	names := []string{}
+	for _, rules := range RuleSets() {
+		names = append(names, rules.Name)
+	}
+	assert.Equal(c, []string{"classical", DefaultRuleSetName}, names)
+}
+
+func (s *RulesTestSuite) TestCopy(c *check.C) {
+	copied := DefaultRuleSet().Copy("copied")
+	copied.Points.SimplePung = 47
+	delete(copied.Detectors, "full flush")
+
+	assert.Equal(c, "copied", copied.Name)
+	assert.Equal(c, 2, DefaultRuleSet().Points.SimplePung)
+	assert.Contains(c, DefaultRuleSet().Detectors, "full flush")
+
+	set := Set{Tiles: []Tile{Bamboo5, Bamboo5, Bamboo5}}
+	points, _, _ := copied.ScoreSet(&set, windOwn, windRound)
+	assert.Equal(c, 47, points)
+}
+
+func (s *RulesTestSuite) TestClassical(c *check.C) {
+	classical, err := LookupRuleSet("classical")
+	assert.Nil(c, err)
+
+	// The own wind counts twice when it's also the round wind.
+	assertSetScore := func(expectPoints, expectDoubles int, set Set) {
+		points, doubles, _ := classical.ScoreSet(&set, WindWest, WindWest)
+		assert.Equal(c, expectPoints, points)
+		assert.Equal(c, expectDoubles, doubles)
+	}
+	assertSetScore(4, 0, Set{Tiles: []Tile{WindWest, WindWest}})
+	assertSetScore(4, 2, Set{Tiles: []Tile{WindWest, WindWest, WindWest}})
+	assertSetScore(0, 0, Set{Tiles: []Tile{WindEast, WindEast}})
+	assertSetScore(4, 0, Set{Tiles: []Tile{WindEast, WindEast, WindEast}})
+
+	// A full flush scores fewer doubles.
+	hand := &Hand{
+		Sets: []Set{
+			Set{Tiles: []Tile{Balls9, Balls9}},
+			Set{Tiles: []Tile{Balls2, Balls3, Balls4}},
+			Set{Tiles: []Tile{Balls5, Balls6, Balls7}},
+			Set{Tiles: []Tile{Balls1, Balls1, Balls1, Balls1}},
+			Set{Tiles: []Tile{Balls8, Balls8, Balls8}},
+		},
+		WindOwn:   windOwn,
+		WindRound: windRound,
+	}
+	assert.Equal(c, (16+2+20)*(1<<3), classical.Score(hand))
+	assert.Equal(c, (16+2+20)*(1<<4), Score(hand))
+}
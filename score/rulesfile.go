@@ -0,0 +1,208 @@
+/*
+ * Loading of house rules from a YAML file.
+ *
+ * A rules file starts from an existing rule set, and overrides only what
+ * it mentions. See rules-example.yaml in the project root.
+ */
+
+package score
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ruleSetFile mirrors the structure of a rules file.
+type ruleSetFile struct {
+	Name            string          `yaml:"name"`
+	Description     string          `yaml:"description"`
+	Base            string          `yaml:"base"`
+	Points          SetPoints       `yaml:"points"`
+	WinningBonus    int             `yaml:"winning_bonus"`
+	BonusTilePoints int             `yaml:"bonus_tile_points"`
+	Limit           int             `yaml:"limit"`
+	LimitHandScore  int             `yaml:"limit_hand_score"`
+	Settlement      SettlementRules `yaml:"settlement"`
+	Dealer          DealerRules     `yaml:"dealer"`
+	Doubles         map[string]int  `yaml:"doubles"`
+	BonusPoints     map[string]int  `yaml:"bonus_points"`
+	LimitHands      map[string]bool `yaml:"limit_hands"`
+}
+
+// RuleSetError is returned when a rules file is not valid.
+type RuleSetError struct {
+	Source string
+	Errors []string
+}
+
+func (e *RuleSetError) Error() string {
+	return fmt.Sprintf("invalid rules in %s:\n  %s", e.Source, strings.Join(e.Errors, "\n  "))
+}
+
+// fixedPoints returns a points detector that awards the given number of points
+// whenever the wrapped detector finds any.
+func fixedPoints(detector PointsDetector, points int) PointsDetector {
+	return func(hand *Hand) int {
+		if detector(hand) == 0 {
+			return 0
+		}
+		return points
+	}
+}
+
+func sortedKeys(names map[string]bool) string {
+	keys := make([]string, 0, len(names))
+	for key := range names {
+		keys = append(keys, fmt.Sprintf("%q", key))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+// LoadRuleSet reads a rule set from a YAML file.
+func LoadRuleSet(filename string) (*RuleSet, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRuleSet(data, filename)
+}
+
+// ParseRuleSet reads a rule set from YAML. The source is only used in error messages.
+func ParseRuleSet(data []byte, source string) (*RuleSet, error) {
+	// Find the base rule set before parsing the rest, so that the file only
+	// needs to mention what it changes.
+	var header struct {
+		Base string `yaml:"base"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return nil, &RuleSetError{source, []string{err.Error()}}
+	}
+	if header.Base == "" {
+		header.Base = DefaultRuleSetName
+	}
+	base, err := LookupRuleSet(header.Base)
+	if err != nil {
+		return nil, &RuleSetError{source, []string{"base: " + err.Error()}}
+	}
+
+	file := ruleSetFile{
+		Description:     base.Description,
+		Points:          base.Points,
+		WinningBonus:    base.WinningBonus,
+		BonusTilePoints: base.BonusTilePoints,
+		Limit:           base.Limit,
+		LimitHandScore:  base.LimitHandScore,
+		Settlement:      base.Settlement,
+		Dealer:          base.Dealer,
+	}
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, &RuleSetError{source, []string{err.Error()}}
+	}
+
+	errs := []string{}
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+
+	if file.Name == "" {
+		invalid("name: a rule set needs a name")
+	}
+	nonNegative := map[string]int{
+		"points.simple_pung":   file.Points.SimplePung,
+		"points.terminal_pung": file.Points.TerminalPung,
+		"points.honour_pung":   file.Points.HonourPung,
+		"points.dragon_pillow": file.Points.DragonPillow,
+		"points.wind_pillow":   file.Points.WindPillow,
+		"points.dragon_pung":   file.Points.DragonPung,
+		"points.wind_pung":     file.Points.WindPung,
+		"winning_bonus":        file.WinningBonus,
+		"bonus_tile_points":    file.BonusTilePoints,
+		"limit":                file.Limit,
+		"limit_hand_score":     file.LimitHandScore,
+	}
+	for field, value := range nonNegative {
+		if value < 0 {
+			invalid("%s: must not be negative, not %d", field, value)
+		}
+	}
+	if file.Limit == 0 && file.LimitHandScore < 1 {
+		invalid("limit_hand_score: limit hands need a score when there is no limit")
+	}
+	if file.Points.Concealed < 1 {
+		invalid("points.concealed: multiplier must be at least 1, not %d", file.Points.Concealed)
+	}
+	if file.Points.Kong < 1 {
+		invalid("points.kong: multiplier must be at least 1, not %d", file.Points.Kong)
+	}
+
+	rules := base.Copy(file.Name)
+	rules.Description = file.Description
+	rules.Points = file.Points
+	rules.WinningBonus = file.WinningBonus
+	rules.BonusTilePoints = file.BonusTilePoints
+	rules.Limit = file.Limit
+	rules.LimitHandScore = file.LimitHandScore
+	rules.Settlement = file.Settlement
+	rules.Dealer = file.Dealer
+
+	// Every detector we know of can be enabled, even when the base rule set doesn't use it.
+	knownDetectors := map[string]bool{}
+	for label := range detectors {
+		knownDetectors[label] = true
+	}
+	for label, doubles := range file.Doubles {
+		switch {
+		case !knownDetectors[label]:
+			invalid("doubles: unknown hand %q, choose from %s", label, sortedKeys(knownDetectors))
+		case doubles < 0:
+			invalid("doubles: %q must not be negative, not %d", label, doubles)
+		case doubles == 0:
+			delete(rules.Detectors, label)
+		default:
+			rules.Detectors[label] = fixedDoubles(detectors[label], doubles)
+		}
+	}
+
+	knownPoints := map[string]bool{}
+	for label := range pointsDetectors {
+		knownPoints[label] = true
+	}
+	for label, points := range file.BonusPoints {
+		switch {
+		case !knownPoints[label]:
+			invalid("bonus_points: unknown condition %q, choose from %s", label, sortedKeys(knownPoints))
+		case points < 0:
+			invalid("bonus_points: %q must not be negative, not %d", label, points)
+		case points == 0:
+			delete(rules.PointsDetectors, label)
+		default:
+			rules.PointsDetectors[label] = fixedPoints(pointsDetectors[label], points)
+		}
+	}
+
+	knownLimitHands := map[string]bool{}
+	for label := range limitHands {
+		knownLimitHands[label] = true
+	}
+	for label, enabled := range file.LimitHands {
+		switch {
+		case !knownLimitHands[label]:
+			invalid("limit_hands: unknown hand %q, choose from %s", label, sortedKeys(knownLimitHands))
+		case enabled:
+			rules.LimitHands[label] = limitHands[label]
+		default:
+			delete(rules.LimitHands, label)
+		}
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, &RuleSetError{source, errs}
+	}
+	return rules, nil
+}
@@ -0,0 +1,114 @@
+package score
+
+import (
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+)
+
+type RulesFileTestSuite struct{}
+
+var _ = check.Suite(&RulesFileTestSuite{})
+
+func (s *RulesFileTestSuite) TestParseRuleSet(c *check.C) {
+	rules, err := ParseRuleSet([]byte(`
+name: table-3
+base: classical
+points:
+  dragon_pillow: 4
+limit: 500
+dealer:
+  keeps_on_draw: true
+doubles:
+  full flush: 2
+  pure straight: 1
+  half-flush: 0
+bonus_points:
+  self-drawn: 10
+limit_hands:
+  small four winds: true
+  all kongs: false
+`), "test")
+	assert.Nil(c, err)
+	if rules == nil {
+		return
+	}
+
+	classical, _ := LookupRuleSet("classical")
+	assert.Equal(c, "table-3", rules.Name)
+	assert.Equal(c, classical.Description, rules.Description)
+	assert.Equal(c, 4, rules.Points.DragonPillow)
+	assert.Equal(c, 2, rules.Points.SimplePung)
+	assert.True(c, rules.Points.DoubleWind)
+	assert.Equal(c, 500, rules.Limit)
+	assert.Equal(c, DealerRules{KeepsOnWin: true, KeepsOnDraw: true}, rules.Dealer)
+	assert.False(c, classical.Dealer.KeepsOnDraw)
+	assert.Contains(c, rules.Detectors, "pure straight")
+	assert.NotContains(c, rules.Detectors, "half-flush")
+	assert.Contains(c, classical.Detectors, "half-flush")
+	assert.Contains(c, rules.LimitHands, "small four winds")
+	assert.NotContains(c, classical.LimitHands, "small four winds")
+	assert.NotContains(c, rules.LimitHands, "all kongs")
+
+	hand := &Hand{
+		Sets: []Set{
+			Set{Tiles: []Tile{Balls9, Balls9}},
+			Set{Tiles: []Tile{Balls2, Balls3, Balls4}},
+			Set{Tiles: []Tile{Balls5, Balls6, Balls7}},
+			Set{Tiles: []Tile{Balls1, Balls1, Balls1, Balls1}},
+			Set{Tiles: []Tile{Balls8, Balls8, Balls8}},
+		},
+		WindOwn:      windOwn,
+		WindRound:    windRound,
+		WinSelfDrawn: true,
+	}
+	assert.Equal(c, (16+2+20+10)*(1<<2), rules.Score(hand))
+}
+
+func (s *RulesFileTestSuite) TestParseRuleSetErrors(c *check.C) {
+	_, err := ParseRuleSet([]byte(`
+points:
+  concealed: 0
+  simple_pung: -2
+doubles:
+  fool flush: 2
+  full flush: -1
+limit_hands:
+  twelve orphans: true
+`), "test.yaml")
+	assert.Equal(c, `invalid rules in test.yaml:
+  doubles: "full flush" must not be negative, not -1
+  doubles: unknown hand "fool flush", choose from "all flowers", "all pungs", "all seasons", `+
+		`"all simples", "all terminals/honours", "chow hand", "full flush", "half-flush", "last tile of wall", `+
+		`"out in draw", "outside hand", "own flower", "own season", "pure straight", "replacement tile", `+
+		`"robbed the kong", "three concealed pungs"
+  limit_hands: unknown hand "twelve orphans", choose from "all honours", "all kongs", "big four winds", `+
+		`"big three dragons", "earthly hand", "four concealed pungs", "heavenly hand", "nine gates", `+
+		`"small four winds", "thirteen orphans"
+  name: a rule set needs a name
+  points.concealed: multiplier must be at least 1, not 0
+  points.simple_pung: must not be negative, not -2`, err.Error())
+
+	_, err = ParseRuleSet([]byte("name: x\nbase: nonexistent\n"), "test.yaml")
+	assert.Equal(c, `invalid rules in test.yaml:
+  base: "nonexistent": unknown rule set`, err.Error())
+
+	_, err = ParseRuleSet([]byte("name: x\nlimmit: 500\n"), "test.yaml")
+	assert.Contains(c, err.Error(), "field limmit not found")
+
+	_, err = ParseRuleSet([]byte("name: x\nlimit: 0\nlimit_hand_score: 0\n"), "test.yaml")
+	assert.Equal(c, `invalid rules in test.yaml:
+  limit_hand_score: limit hands need a score when there is no limit`, err.Error())
+
+	rules, err := ParseRuleSet([]byte("name: x\nlimit: 0\n"), "test.yaml")
+	assert.Nil(c, err)
+	assert.Equal(c, 0, rules.Limit)
+	assert.Equal(c, 1000, rules.LimitHandScore, "taken from the base rule set")
+}
+
+func (s *RulesFileTestSuite) TestLoadExample(c *check.C) {
+	rules, err := LoadRuleSet("../rules-example.yaml")
+	assert.Nil(c, err)
+	if rules != nil {
+		assert.Equal(c, "table-3", rules.Name)
+	}
+}
@@ -1,6 +1,7 @@
 package score
 
 import (
+	"math"
 	"sort"
 
 	log "github.com/sirupsen/logrus"
@@ -22,7 +23,7 @@ func (set *Set) IsValid() (bool, bool) {
 	var isChow bool
 
 	for idx, tile := range set.Tiles {
-		if !tile.IsValid() {
+		if !tile.IsValid() || tile.IsBonus() {
 			return false, false
 		}
 
@@ -43,10 +44,16 @@ func (set *Set) IsValid() (bool, bool) {
 	return true, false
 }
 
-// Score returns (basic score, doubles, valid) for the given set.
+// Score returns (basic score, doubles, valid) for the given set, using the default rule set.
 // The returned 'basic' score is not yet multiplied by the doubles.
 func (set *Set) Score(windOwn, windRound Tile) (int, int, bool) {
-	// TODO: count score & doubles for flowers & seasons.
+	return DefaultRuleSet().ScoreSet(set, windOwn, windRound)
+}
+
+// ScoreSet returns (basic score, doubles, valid) for the given set.
+// The returned 'basic' score is not yet multiplied by the doubles.
+func (rules *RuleSet) ScoreSet(set *Set, windOwn, windRound Tile) (int, int, bool) {
+	// Flowers and seasons are not part of any set; they are scored as the hand's bonus tiles.
 	if len(set.Tiles) < 2 {
 		set.setType = NoSet
 		return 0, 0, false
@@ -64,20 +71,27 @@ func (set *Set) Score(windOwn, windRound Tile) (int, int, bool) {
 
 	// If we're here, we know it's a pillow/pung/kong, so the
 	// length and first tile determine the score.
+	points := &rules.Points
 	tile := set.Tiles[0]
-	scoringWind := tile == windOwn || tile == windRound
+	scoringWinds := 0
+	if tile == windOwn {
+		scoringWinds++
+	}
+	if tile == windRound && (scoringWinds == 0 || points.DoubleWind) {
+		scoringWinds++
+	}
 
-	// A concealed pung/kong scores double
+	// A concealed pung/kong scores more
 	var multiplier int
 	if set.Concealed {
-		multiplier = 2
+		multiplier = points.Concealed
 	} else {
 		multiplier = 1
 	}
 
-	// A kong scores 4 times as much as a pung.
+	// A kong scores more than a pung.
 	if len(set.Tiles) == 4 {
-		multiplier *= 4
+		multiplier *= points.Kong
 		set.setType = Kong
 	} else {
 		set.setType = Pung
@@ -87,43 +101,133 @@ func (set *Set) Score(windOwn, windRound Tile) (int, int, bool) {
 	case 2:
 		set.setType = Pillow
 		switch {
-		case scoringWind:
-			return 2, 0, true
+		case scoringWinds > 0:
+			return points.WindPillow * scoringWinds, 0, true
 		case tile.IsDragon():
-			return 2, 0, true
+			return points.DragonPillow, 0, true
 		default:
 			return 0, 0, true
 		}
 	case 3, 4:
 		switch {
 		case tile.IsTerminal():
-			return 4 * multiplier, 0, true
+			return points.TerminalPung * multiplier, 0, true
 		case tile.IsWind():
-			var double int
-			if scoringWind {
-				double = 1
-			} else {
-				double = 0
-			}
-			return 4 * multiplier, double, true
+			return points.HonourPung * multiplier, points.WindPung * scoringWinds, true
 		case tile.IsDragon():
-			return 4 * multiplier, 1, true
+			return points.HonourPung * multiplier, points.DragonPung, true
 		default:
-			return 2 * multiplier, 0, true
+			return points.SimplePung * multiplier, 0, true
 		}
 	}
 
 	panic("Impossible situation turned out to be possible after all.")
 }
 
-// Score calculates the score for the given hand.
+// SetScore is the score of a single set, as part of a Breakdown.
+type SetScore struct {
+	Set
+	Type    SetType `json:"type"`
+	Points  int     `json:"points"`
+	Doubles int     `json:"doubles"`
+	Valid   bool    `json:"valid"`
+}
+
+// PointsScore is a hand-wide amount of points, as part of a Breakdown.
+type PointsScore struct {
+	Name   string `json:"name"`
+	Points int    `json:"points"`
+}
+
+// DetectorScore is a hand-wide double, as part of a Breakdown.
+type DetectorScore struct {
+	Name    string `json:"name"`
+	Doubles int    `json:"doubles"`
+}
+
+// Breakdown explains how the score of a hand was calculated.
+type Breakdown struct {
+	Sets         []SetScore      `json:"sets"`
+	WinningBonus int             `json:"winning_bonus"`
+	BonusTiles   []Tile          `json:"bonus_tiles"`
+	BonusPoints  int             `json:"bonus_points"`
+	Points       []PointsScore   `json:"points"`      // Only the points detectors that found points.
+	BasicScore   int             `json:"basic_score"` // Set points, winning bonus, bonus tile and detector points.
+	Detectors    []DetectorScore `json:"detectors"`   // Only the detectors that found doubles.
+	Doubles      int             `json:"doubles"`     // Set doubles plus detector doubles.
+	LimitHands   []string        `json:"limit_hands"` // When not empty, the score is the rule set's limit.
+	Limit        int             `json:"limit"`
+	LimitReached bool            `json:"limit_reached"`
+	Score        int             `json:"score"`
+	Winning      bool            `json:"winning"`
+}
+
+// maxScore is the highest possible score, even for rule sets without limit.
+// It prevents overflows when doubling.
+const maxScore = math.MaxInt32
+
+// double returns the score multiplied by 2^doubles, and whether it was capped at the limit.
+func (rules *RuleSet) double(basicScore, doubles int) (int, bool) {
+	limit := rules.Limit
+	if limit <= 0 || limit > maxScore {
+		limit = maxScore
+	}
+
+	score := basicScore
+	for idx := 0; idx < doubles && score < limit; idx++ {
+		score *= 2
+	}
+	if score > limit {
+		return limit, true
+	}
+	return score, score == limit && rules.Limit > 0
+}
+
+// Score calculates the score for the given hand, using the default rule set.
 func Score(hand *Hand) int {
-	totalScore := 0
-	totalDoubles := 0
+	return DefaultRuleSet().Score(hand)
+}
+
+// ScoreBreakdown calculates the score for the given hand using the default rule set,
+// and explains how it got there.
+func ScoreBreakdown(hand *Hand) Breakdown {
+	return DefaultRuleSet().ScoreBreakdown(hand)
+}
+
+// limitHandScore returns the score of limit hands: the limit, or without limit
+// the score set for them. Without either, they score the highest possible score.
+func (rules *RuleSet) limitHandScore() int {
+	switch {
+	case rules.Limit > 0:
+		return rules.Limit
+	case rules.LimitHandScore > 0:
+		return rules.LimitHandScore
+	}
+	return maxScore
+}
+
+// Score calculates the score for the given hand.
+func (rules *RuleSet) Score(hand *Hand) int {
+	return rules.ScoreBreakdown(hand).Score
+}
+
+// ScoreBreakdown calculates the score for the given hand, and explains how it got there.
+func (rules *RuleSet) ScoreBreakdown(hand *Hand) Breakdown {
+	breakdown := Breakdown{
+		Sets:       make([]SetScore, 0, len(hand.Sets)),
+		Detectors:  []DetectorScore{},
+		BonusTiles: []Tile{},
+		Points:     []PointsScore{},
+		LimitHands: []string{},
+		Limit:      rules.Limit,
+	}
 	nrOfPungs := 0
 	nrOfPillows := 0
 
-	log.WithField("hand", hand).Debug("calculating hand score")
+	log.WithFields(log.Fields{
+		"hand":    hand,
+		"ruleset": rules.Name,
+	}).Debug("calculating hand score")
 
 	// Sorting the sets makes it easier to detect pure straights, nine gates and others.
 	sort.Sort(SortSetsByTileOrder(hand.Sets))
@@ -131,13 +235,20 @@ func Score(hand *Hand) int {
 	// Start by summing up the tile set scores.
 	for idx := range hand.Sets {
 		set := &hand.Sets[idx]
-		setScore, setDoubles, isValid := set.Score(hand.WindOwn, hand.WindRound)
+		setScore, setDoubles, isValid := rules.ScoreSet(set, hand.WindOwn, hand.WindRound)
 		log.WithFields(log.Fields{
 			"set-idx": idx,
 			"score":   setScore,
 			"doubles": setDoubles,
 			"valid":   isValid,
 		}).Debug("set score calculated")
+		breakdown.Sets = append(breakdown.Sets, SetScore{
+			Set:     *set,
+			Type:    set.setType,
+			Points:  setScore,
+			Doubles: setDoubles,
+			Valid:   isValid,
+		})
 		if !isValid {
 			continue
 		}
@@ -149,34 +260,101 @@ func Score(hand *Hand) int {
 			nrOfPungs++
 		}
 
-		totalScore += setScore
-		totalDoubles += setDoubles
+		breakdown.BasicScore += setScore
+		breakdown.Doubles += setDoubles
 	}
 
 	// Detect winning hand
 	if nrOfPungs == 4 && nrOfPillows == 1 {
-		totalScore += 20
+		breakdown.WinningBonus = rules.WinningBonus
+		breakdown.BasicScore += breakdown.WinningBonus
 		hand.Winning = true
 	} else {
 		hand.Winning = false
 	}
+	breakdown.Winning = hand.Winning
+
+	// A limit hand scores the limit, regardless of anything else.
+	labels := make([]string, 0, len(rules.LimitHands))
+	for label := range rules.LimitHands {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	for _, label := range labels {
+		if rules.LimitHands[label](hand) {
+			breakdown.LimitHands = append(breakdown.LimitHands, label)
+		}
+	}
+	if len(breakdown.LimitHands) > 0 {
+		hand.Winning = true
+		breakdown.Winning = true
+		breakdown.Score = rules.limitHandScore()
+		breakdown.LimitReached = true
+		log.WithFields(log.Fields{
+			"limit-hands": breakdown.LimitHands,
+			"score":       breakdown.Score,
+		}).Debug("limit hand detected")
+		return breakdown
+	}
+
+	// Count doubles. The detectors are run in a fixed order, to get a stable breakdown.
+	labels = labels[:0]
+	for label := range rules.Detectors {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
 
-	// Count doubles
-	for label, detector := range detectors {
-		doubles := detector(hand, totalScore)
+	for _, label := range labels {
+		doubles := rules.Detectors[label](hand, breakdown.BasicScore)
 		log.WithFields(log.Fields{
 			"detector": label,
 			"doubles":  doubles,
 		}).Debug("ran detector")
-		totalDoubles += doubles
+		if doubles == 0 {
+			continue
+		}
+		breakdown.Detectors = append(breakdown.Detectors, DetectorScore{label, doubles})
+		breakdown.Doubles += doubles
+	}
+
+	// Flowers, seasons and win conditions are counted after running the detectors,
+	// so that they don't prevent doubles for hands without points.
+	for _, tile := range hand.Bonus {
+		if !tile.IsBonus() {
+			continue
+		}
+		breakdown.BonusTiles = append(breakdown.BonusTiles, tile)
+		breakdown.BonusPoints += rules.BonusTilePoints
+	}
+	breakdown.BasicScore += breakdown.BonusPoints
+
+	labels = labels[:0]
+	for label := range rules.PointsDetectors {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	for _, label := range labels {
+		points := rules.PointsDetectors[label](hand)
+		log.WithFields(log.Fields{
+			"detector": label,
+			"points":   points,
+		}).Debug("ran points detector")
+		if points == 0 {
+			continue
+		}
+		breakdown.Points = append(breakdown.Points, PointsScore{label, points})
+		breakdown.BasicScore += points
 	}
 
-	finalScore := totalScore * 1 << uint(totalDoubles)
+	breakdown.Score, breakdown.LimitReached = rules.double(breakdown.BasicScore, breakdown.Doubles)
 	log.WithFields(log.Fields{
-		"tile-score": totalScore,
-		"doubles":    totalDoubles,
-		"score":      finalScore,
+		"tile-score":    breakdown.BasicScore,
+		"doubles":       breakdown.Doubles,
+		"score":         breakdown.Score,
+		"limit-reached": breakdown.LimitReached,
 	}).Debug("hand score calculated")
 
-	return finalScore
+	return breakdown
 }
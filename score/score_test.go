@@ -3,6 +3,7 @@ package score
 import (
 	"encoding/json"
 
+	"github.com/stretchr/testify/assert"
 	check "gopkg.in/check.v1"
 )
 
@@ -77,6 +78,10 @@ func (s *ScoreTestSuite) TestSetValid(t *check.C) {
 	assertSetValid(true, true, Set{Tiles: []Tile{Balls5, Balls6, Balls7}})
 	assertSetValid(true, false, Set{Tiles: []Tile{Balls1, Balls1, Balls1, Balls1}})
 	assertSetValid(true, false, Set{Tiles: []Tile{Balls8, Balls8, Balls8}})
+
+	// Flowers and seasons never form a set.
+	assertSetValid(false, false, Set{Tiles: []Tile{Flower1, Flower1, Flower1}})
+	assertSetValid(false, false, Set{Tiles: []Tile{Season1, Season2, Season3}})
 }
 
 func (s *ScoreTestSuite) TestIsDragon(t *check.C) {
@@ -144,8 +149,6 @@ func (s *ScoreTestSuite) TestSetScores(t *check.C) {
 			expected_score, expected_doubles, expected_type, score, doubles, set.setType)
 	}
 
-	// TODO: count score & doubles for flowers & seasons.
-
 	assertSetScore(0, 0, NoSet, &Set{})
 
 	// Simples: chow, pung, and kong
@@ -480,3 +483,191 @@ func (s *ScoreTestSuite) TestTile_Suit(t *check.C) {
 	assert(NoTile, Bamboo9+1)
 	assert(NoTile, Season3)
 }
+
+func (s *ScoreTestSuite) TestScoreBreakdown(t *check.C) {
+	hand := &Hand{
+		Sets: []Set{
+			Set{Tiles: []Tile{DragonGreen, DragonGreen}},
+			Set{Tiles: []Tile{WindWest, WindWest, WindWest}},
+			Set{Tiles: []Tile{Chars1, Chars2, Chars3}},
+			Set{Tiles: []Tile{Chars4, Chars5, Chars6}},
+			Set{Tiles: []Tile{Chars2, Chars2, Chars2}},
+		},
+		WindOwn:   windOwn,
+		WindRound: windRound,
+	}
+	breakdown := ScoreBreakdown(hand)
+
+	assert.Equal(t, []SetScore{
+		SetScore{Set: Set{Tiles: []Tile{Chars1, Chars2, Chars3}, setType: Chow}, Type: Chow, Valid: true},
+		SetScore{Set: Set{Tiles: []Tile{Chars2, Chars2, Chars2}, setType: Pung}, Type: Pung, Points: 2, Valid: true},
+		SetScore{Set: Set{Tiles: []Tile{Chars4, Chars5, Chars6}, setType: Chow}, Type: Chow, Valid: true},
+		SetScore{Set: Set{Tiles: []Tile{WindWest, WindWest, WindWest}, setType: Pung}, Type: Pung, Points: 4, Doubles: 1, Valid: true},
+		SetScore{Set: Set{Tiles: []Tile{DragonGreen, DragonGreen}, setType: Pillow}, Type: Pillow, Points: 2, Valid: true},
+	}, breakdown.Sets)
+	assert.Equal(t, 20, breakdown.WinningBonus)
+	assert.Equal(t, 28, breakdown.BasicScore)
+	assert.Equal(t, []DetectorScore{DetectorScore{"half-flush", 1}}, breakdown.Detectors)
+	assert.Equal(t, 2, breakdown.Doubles)
+	assert.Equal(t, 28*4, breakdown.Score)
+	assert.True(t, breakdown.Winning)
+
+	asJSON, err := json.Marshal(breakdown.Sets[0])
+	assert.Nil(t, err)
+	assert.Equal(t, `{"tiles":[21,22,23],"concealed":false,"type":"chow","points":0,"doubles":0,"valid":true}`,
+		string(asJSON))
+}
+
+func (s *ScoreTestSuite) TestScoreBonusTiles(t *check.C) {
+	sets := func() []Set {
+		return []Set{
+			Set{Tiles: []Tile{Balls9, Balls9}},
+			Set{Tiles: []Tile{Bamboo2, Bamboo3, Bamboo4}},
+			Set{Tiles: []Tile{Balls5, Balls6, Balls7}},
+			Set{Tiles: []Tile{Balls1, Balls1, Balls1, Balls1}},
+			Set{Tiles: []Tile{Balls8, Balls8, Balls8}},
+		}
+	}
+
+	// The player's own wind is north, so their own flower & season are number 4.
+	assertScore(t, 16+2+20+4, &Hand{Sets: sets(), Bonus: []Tile{Flower1}})
+	assertScore(t, (16+2+20+8)*2, &Hand{Sets: sets(), Bonus: []Tile{Flower1, Season4}})
+	assertScore(t, (16+2+20+8)*4, &Hand{Sets: sets(), Bonus: []Tile{Flower4, Season4}})
+	assertScore(t, (16+2+20+16)*2*4, &Hand{Sets: sets(), Bonus: []Tile{Flower1, Flower2, Flower3, Flower4}})
+	// (16+2+20+32)*4*4*4 is capped at the limit.
+	assertScore(t, 1000, &Hand{Sets: sets(), Bonus: []Tile{
+		Flower1, Flower2, Flower3, Flower4, Season1, Season2, Season3, Season4}})
+
+	// Bonus tiles also count in a non-winning hand.
+	assertScore(t, (0+4)*2, &Hand{Bonus: []Tile{Season4}})
+
+	// Bonus tiles don't spoil a chow hand.
+	hand := &Hand{
+		Sets: []Set{
+			Set{Tiles: []Tile{Balls2, Balls2}},
+			Set{Tiles: []Tile{Balls1, Balls2, Balls3}},
+			Set{Tiles: []Tile{Chars1, Chars2, Chars3}},
+			Set{Tiles: []Tile{Balls5, Balls6, Balls7}},
+			Set{Tiles: []Tile{Bamboo1, Bamboo2, Bamboo3}},
+		},
+		Bonus: []Tile{Flower2},
+	}
+	assertScore(t, (20+4)*2, hand)
+	if chowHand(hand, 20) == 0 {
+		t.Error("Hand should have been recognised as chow hand.")
+	}
+}
+
+func (s *ScoreTestSuite) TestScoreWinConditions(t *check.C) {
+	winning := func() *Hand {
+		return &Hand{Sets: []Set{
+			Set{Tiles: []Tile{Balls9, Balls9}},
+			Set{Tiles: []Tile{Bamboo2, Bamboo3, Bamboo4}},
+			Set{Tiles: []Tile{Balls5, Balls6, Balls7}},
+			Set{Tiles: []Tile{Balls1, Balls1, Balls1, Balls1}},
+			Set{Tiles: []Tile{Balls8, Balls8, Balls8}},
+		}}
+	}
+	const basic = 16 + 2 + 20
+
+	hand := winning()
+	hand.WinSelfDrawn = true
+	assertScore(t, basic+2, hand)
+
+	hand = winning()
+	hand.LastChance = true
+	assertScore(t, basic+2, hand)
+
+	hand = winning()
+	hand.WinSelfDrawn = true
+	hand.WinOnReplacementTile = true
+	assertScore(t, (basic+2)*2, hand)
+
+	hand = winning()
+	hand.WinSelfDrawn = true
+	hand.LastTileOfWall = true
+	assertScore(t, (basic+2)*2, hand)
+
+	hand = winning()
+	hand.RobbedTheKong = true
+	assertScore(t, basic*2, hand)
+
+	hand = winning()
+	hand.OutInDraw = true
+	assertScore(t, basic*2, hand)
+
+	breakdown := ScoreBreakdown(hand)
+	assert.Equal(t, []DetectorScore{DetectorScore{"out in draw", 1}}, breakdown.Detectors)
+	assert.Empty(t, breakdown.Points)
+
+	// Win conditions mean nothing for a non-winning hand.
+	hand = &Hand{
+		Sets: []Set{
+			Set{Tiles: []Tile{Balls8, Balls8, Balls8}},
+			Set{Tiles: []Tile{Chars2, Chars2}},
+		},
+		WinSelfDrawn:   true,
+		LastTileOfWall: true,
+		RobbedTheKong:  true,
+	}
+	assertScore(t, 2, hand)
+}
+
+func (s *ScoreTestSuite) TestScoreLimit(t *check.C) {
+	hand := func() *Hand {
+		return &Hand{
+			Sets: []Set{
+				Set{Tiles: []Tile{DragonGreen, DragonGreen}},
+				Set{Tiles: []Tile{WindWest, WindWest, WindWest, WindWest}, Concealed: true}, // 32 + 1d
+				Set{Tiles: []Tile{Chars1, Chars2, Chars3}},
+				Set{Tiles: []Tile{Chars4, Chars5, Chars6}},
+				Set{Tiles: []Tile{Chars2, Chars2, Chars2}},
+			},
+			WindOwn:   windOwn,
+			WindRound: windRound,
+			Bonus:     []Tile{Flower1, Flower2, Flower3, Flower4},
+		}
+	}
+
+	// (2+32+2+20+16) * 2^(1 + half flush + own flower + all flowers) = 72 * 2^5 = 2304
+	breakdown := DefaultRuleSet().WithLimit(0).ScoreBreakdown(hand())
+	assert.Equal(t, 2304, breakdown.Score)
+	assert.False(t, breakdown.LimitReached)
+
+	breakdown = DefaultRuleSet().ScoreBreakdown(hand())
+	assert.Equal(t, 1000, breakdown.Score)
+	assert.Equal(t, 1000, breakdown.Limit)
+	assert.True(t, breakdown.LimitReached)
+
+	breakdown = DefaultRuleSet().WithLimit(2304).ScoreBreakdown(hand())
+	assert.Equal(t, 2304, breakdown.Score)
+	assert.True(t, breakdown.LimitReached)
+
+	assert.Equal(t, 1000, DefaultRuleSet().Limit, "WithLimit() should not modify the original")
+
+	// Heaps of doubles shouldn't overflow, even without limit.
+	score, limitReached := DefaultRuleSet().WithLimit(0).double(1000, 200)
+	assert.Equal(t, maxScore, score)
+	assert.True(t, limitReached)
+	score, limitReached = DefaultRuleSet().double(0, 200)
+	assert.Equal(t, 0, score)
+	assert.False(t, limitReached)
+}
+
+func (s *ScoreTestSuite) TestLimitHandWithoutLimit(t *check.C) {
+	hand, err := ParseHand("19b 19c 19s ESWN rgw | 1s @EE")
+	assert.Nil(t, err)
+
+	breakdown := DefaultRuleSet().WithLimit(0).ScoreBreakdown(&hand)
+	assert.Equal(t, []string{"thirteen orphans"}, breakdown.LimitHands)
+	assert.Equal(t, 1000, breakdown.Score)
+	assert.True(t, breakdown.Winning)
+
+	rules := DefaultRuleSet().WithLimit(0)
+	rules.LimitHandScore = 640
+	assert.Equal(t, 640, rules.Score(&hand))
+	assert.Equal(t, 500, DefaultRuleSet().WithLimit(500).Score(&hand), "the limit goes first")
+
+	rules.LimitHandScore = 0
+	assert.Equal(t, maxScore, rules.Score(&hand))
+}
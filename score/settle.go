@@ -0,0 +1,120 @@
+/*
+ * Settlement of scores between the players after a hand.
+ */
+
+package score
+
+import (
+	"errors"
+	"fmt"
+)
+
+// NumPlayers is the number of players at a table.
+const NumPlayers = 4
+
+// Standard errors.
+var (
+	ErrInvalidSettlement = errors.New("invalid settlement")
+)
+
+// SettlementRules describe who pays whom after a hand.
+type SettlementRules struct {
+	// Whether the losers also pay each other the difference between their scores.
+	LosersPayEachOther bool `json:"losers_pay_each_other" yaml:"losers_pay_each_other"`
+	// Whether the player who discarded the winning tile pays the winner on behalf of all losers.
+	DiscarderPaysAll bool `json:"discarder_pays_all" yaml:"discarder_pays_all"`
+}
+
+// Settlement describes the outcome of a hand, from which the payments follow.
+// Players are identified by their index, 0-3.
+type Settlement struct {
+	Scores    [NumPlayers]int `json:"scores"`    // Hand score of each player.
+	Winner    int             `json:"winner"`    // The winning player, or -1 when nobody won.
+	Discarder int             `json:"discarder"` // The player who discarded the winning tile, or -1 when self-drawn.
+	East      int             `json:"east"`      // The dealer, who pays and receives double.
+}
+
+func validPlayer(player int) bool {
+	return player >= 0 && player < NumPlayers
+}
+
+func (s *Settlement) validate() error {
+	switch {
+	case !validPlayer(s.East):
+		return fmt.Errorf("%s: east must be a player, not %d", ErrInvalidSettlement, s.East)
+	case s.Winner != -1 && !validPlayer(s.Winner):
+		return fmt.Errorf("%s: winner must be a player or -1, not %d", ErrInvalidSettlement, s.Winner)
+	case s.Discarder != -1 && !validPlayer(s.Discarder):
+		return fmt.Errorf("%s: discarder must be a player or -1, not %d", ErrInvalidSettlement, s.Discarder)
+	case s.Discarder != -1 && s.Discarder == s.Winner:
+		return fmt.Errorf("%s: the winner cannot have discarded the winning tile", ErrInvalidSettlement)
+	case s.Discarder != -1 && s.Winner == -1:
+		return fmt.Errorf("%s: there is no discarder without a winner", ErrInvalidSettlement)
+	}
+	return nil
+}
+
+// Settle returns how much each player gains (positive) or pays (negative).
+// The winner receives their score from every other player. East pays and
+// receives double. When nobody won, nobody pays.
+func (rules *RuleSet) Settle(settlement Settlement) ([NumPlayers]int, error) {
+	deltas := [NumPlayers]int{}
+	if err := settlement.validate(); err != nil {
+		return deltas, err
+	}
+	if settlement.Winner == -1 {
+		return deltas, nil
+	}
+
+	pay := func(from, to, amount int) {
+		if from == settlement.East || to == settlement.East {
+			amount *= 2
+		}
+		deltas[from] -= amount
+		deltas[to] += amount
+	}
+
+	winner := settlement.Winner
+	discarderPays := rules.Settlement.DiscarderPaysAll && settlement.Discarder != -1
+	for loser := range deltas {
+		if loser == winner {
+			continue
+		}
+
+		// East's double pay depends on who owes, even when someone else pays.
+		amount := settlement.Scores[winner]
+		if loser == settlement.East || winner == settlement.East {
+			amount *= 2
+		}
+		payer := loser
+		if discarderPays {
+			payer = settlement.Discarder
+		}
+		deltas[payer] -= amount
+		deltas[winner] += amount
+	}
+
+	if !rules.Settlement.LosersPayEachOther {
+		return deltas, nil
+	}
+	for one := range deltas {
+		for other := one + 1; other < NumPlayers; other++ {
+			if one == winner || other == winner {
+				continue
+			}
+			difference := settlement.Scores[one] - settlement.Scores[other]
+			if difference > 0 {
+				pay(other, one, difference)
+			} else if difference < 0 {
+				pay(one, other, -difference)
+			}
+		}
+	}
+
+	return deltas, nil
+}
+
+// Settle settles the hand with the default rule set. See RuleSet.Settle().
+func Settle(settlement Settlement) ([NumPlayers]int, error) {
+	return DefaultRuleSet().Settle(settlement)
+}
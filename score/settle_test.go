@@ -0,0 +1,73 @@
+package score
+
+import (
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+)
+
+type SettleTestSuite struct{}
+
+var _ = check.Suite(&SettleTestSuite{})
+
+func (s *SettleTestSuite) TestSettle(c *check.C) {
+	// Player 2 wins with 40 points, player 0 is East.
+	settlement := Settlement{
+		Scores:    [NumPlayers]int{10, 20, 40, 4},
+		Winner:    2,
+		Discarder: 1,
+		East:      0,
+	}
+
+	deltas, err := Settle(settlement)
+	assert.Nil(c, err)
+	// Winner: 80 from East, 40 from both others.
+	// Losers: East receives 2×(10-4)=12 from player 3, pays 2×(20-10)=20 to player 1,
+	// and player 1 receives 20-4=16 from player 3.
+	assert.Equal(c, [NumPlayers]int{-80 + 12 - 20, -40 + 20 + 16, 160, -40 - 12 - 16}, deltas)
+
+	// Without losers paying each other.
+	rules := DefaultRuleSet().Copy("test")
+	rules.Settlement.LosersPayEachOther = false
+	deltas, err = rules.Settle(settlement)
+	assert.Nil(c, err)
+	assert.Equal(c, [NumPlayers]int{-80, -40, 160, -40}, deltas)
+
+	// The discarder pays for everybody.
+	rules.Settlement.DiscarderPaysAll = true
+	deltas, err = rules.Settle(settlement)
+	assert.Nil(c, err)
+	assert.Equal(c, [NumPlayers]int{0, -160, 160, 0}, deltas)
+
+	// Self-drawn, so the discarder rule doesn't apply.
+	settlement.Discarder = -1
+	deltas, err = rules.Settle(settlement)
+	assert.Nil(c, err)
+	assert.Equal(c, [NumPlayers]int{-80, -40, 160, -40}, deltas)
+
+	// East wins.
+	settlement.Winner = 0
+	deltas, err = rules.Settle(settlement)
+	assert.Nil(c, err)
+	assert.Equal(c, [NumPlayers]int{60, -20, -20, -20}, deltas)
+
+	// Nobody wins.
+	settlement.Winner = -1
+	deltas, err = Settle(settlement)
+	assert.Nil(c, err)
+	assert.Equal(c, [NumPlayers]int{}, deltas)
+}
+
+func (s *SettleTestSuite) TestSettleInvalid(c *check.C) {
+	assertInvalid := func(settlement Settlement) {
+		_, err := Settle(settlement)
+		if assert.NotNil(c, err) {
+			assert.Contains(c, err.Error(), ErrInvalidSettlement.Error())
+		}
+	}
+
+	assertInvalid(Settlement{Winner: 4, Discarder: -1})
+	assertInvalid(Settlement{Winner: 1, Discarder: 1})
+	assertInvalid(Settlement{Winner: -1, Discarder: 1})
+	assertInvalid(Settlement{Winner: 1, Discarder: -2})
+	assertInvalid(Settlement{Winner: 1, Discarder: -1, East: 5})
+}
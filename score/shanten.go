@@ -0,0 +1,240 @@
+/*
+ * Shanten: how many tiles a hand is away from being ready.
+ *
+ * The suits don't influence each other, so the shapes each suit can form
+ * are found separately and then combined.
+ */
+
+package score
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Standard errors.
+var (
+	ErrTooManyTiles = errors.New("more than four of a tile")
+)
+
+// shape is a combination of sets, partial sets (two tiles that need a third)
+// and pillows that tiles can be split up into.
+type shape struct {
+	sets, partials, pillows int
+}
+
+// groupShapes finds every shape that the tiles of a single suit, or the honours, can form.
+type groupShapes struct {
+	counts  []int
+	chows   bool
+	current shape
+	found   map[shape]bool
+}
+
+func (g *groupShapes) search(from int) {
+	idx := from
+	for idx < len(g.counts) && g.counts[idx] == 0 {
+		idx++
+	}
+	if idx == len(g.counts) {
+		g.found[g.current] = true
+		return
+	}
+
+	try := func(counter *int, offsets ...int) {
+		for _, offset := range offsets {
+			g.counts[idx+offset]--
+		}
+		*counter++
+		g.search(idx)
+		*counter--
+		for _, offset := range offsets {
+			g.counts[idx+offset]++
+		}
+	}
+	next := func(offset int) bool {
+		return g.chows && idx+offset < len(g.counts) && g.counts[idx+offset] > 0
+	}
+
+	count := g.counts[idx]
+	if count >= 3 {
+		try(&g.current.sets, 0, 0, 0)
+	}
+	if next(1) && next(2) {
+		try(&g.current.sets, 0, 1, 2)
+	}
+	if count >= 2 {
+		if g.current.pillows == 0 {
+			try(&g.current.pillows, 0, 0)
+		}
+		try(&g.current.partials, 0, 0)
+	}
+	if next(1) {
+		try(&g.current.partials, 0, 1)
+	}
+	if next(2) {
+		try(&g.current.partials, 0, 2)
+	}
+
+	// Leave the tile out.
+	g.counts[idx]--
+	g.search(idx)
+	g.counts[idx]++
+}
+
+// shapeKey identifies a group of tiles in the shape cache.
+type shapeKey struct {
+	counts [9]byte
+	chows  bool
+}
+
+// maxCachedShapes limits the number of groups of tiles that shapesOf remembers.
+const maxCachedShapes = 1 << 16
+
+// shapeCache remembers the shapes of groups of tiles, as the same groups
+// occur over and over when searching for the best discard.
+var (
+	shapeCache  sync.Map
+	shapeCached int64
+)
+
+// shapesOf returns the shapes that the tiles of a single suit can form.
+func shapesOf(counts []int, chows bool) []shape {
+	key := shapeKey{chows: chows}
+	for idx, count := range counts {
+		key.counts[idx] = byte(count)
+	}
+	if cached, found := shapeCache.Load(key); found {
+		return cached.([]shape)
+	}
+
+	group := groupShapes{
+		counts: append([]int{}, counts...),
+		chows:  chows,
+		found:  map[shape]bool{},
+	}
+	group.search(0)
+
+	shapes := make([]shape, 0, len(group.found))
+	for found := range group.found {
+		shapes = append(shapes, found)
+	}
+	if atomic.AddInt64(&shapeCached, 1) <= maxCachedShapes {
+		shapeCache.Store(key, shapes)
+	}
+	return shapes
+}
+
+// CheckTileCounts returns an error when there are more than four of a tile,
+// which no set of mahjong tiles has.
+func CheckTileCounts(tiles []Tile) error {
+	counts := map[Tile]int{}
+	for _, tile := range tiles {
+		counts[tile]++
+		if counts[tile] > 4 {
+			return fmt.Errorf("%s: %s", ErrTooManyTiles, FormatTiles([]Tile{tile}))
+		}
+	}
+	return nil
+}
+
+// ShantenTiles returns how many tiles the concealed tiles are away from a ready hand,
+// given the number of sets that are already melded. A ready hand has shanten 0, and a
+// winning hand -1. Both hands of four sets and a pillow and the thirteen orphans are
+// taken into account. There are only four of each tile, so any more are left out; use
+// CheckTileCounts to reject such tiles instead.
+func ShantenTiles(concealed []Tile, melded int) int {
+	var counts [flowerBase]int
+	for _, tile := range concealed {
+		// Bonus tiles are never part of a set.
+		if !tile.IsValid() || int(tile) >= len(counts) || counts[tile] == 4 {
+			continue
+		}
+		counts[tile]++
+	}
+
+	// Combine the shapes of the suits, one suit at a time. Only four sets and
+	// partial sets are useful, so the totals are capped there.
+	var totals [5][5][2]bool
+	if melded > 4 {
+		melded = 4
+	}
+	totals[melded][0][0] = true
+	for _, base := range []Tile{ballsBase, charsBase, bambooBase, windBase, dragonBase} {
+		shapes := shapesOf(counts[base+1:base+10], base < mayChowBelow)
+
+		var combined [5][5][2]bool
+		for sets := range totals {
+			for partials := range totals[sets] {
+				for pillows := range totals[sets][partials] {
+					if !totals[sets][partials][pillows] {
+						continue
+					}
+					for _, found := range shapes {
+						sum := shape{sets + found.sets, partials + found.partials, pillows + found.pillows}
+						if sum.pillows > 1 {
+							continue
+						}
+						if sum.sets > 4 {
+							sum.sets = 4
+						}
+						if sum.sets+sum.partials > 4 {
+							sum.partials = 4 - sum.sets
+						}
+						combined[sum.sets][sum.partials][sum.pillows] = true
+					}
+				}
+			}
+		}
+		totals = combined
+	}
+
+	best := 8
+	for sets := range totals {
+		for partials := range totals[sets] {
+			for pillows := range totals[sets][partials] {
+				if !totals[sets][partials][pillows] {
+					continue
+				}
+				if shanten := 8 - 2*sets - partials - pillows; shanten < best {
+					best = shanten
+				}
+			}
+		}
+	}
+
+	if melded == 0 {
+		if shanten := thirteenOrphansShanten(counts[:]); shanten < best {
+			return shanten
+		}
+	}
+	return best
+}
+
+// thirteenOrphansShanten counts the missing orphans, and whether one of them can be the pair.
+func thirteenOrphansShanten(counts []int) int {
+	shanten := len(orphans)
+	hasPair := false
+	for _, orphan := range orphans {
+		if counts[orphan] == 0 {
+			continue
+		}
+		shanten--
+		if counts[orphan] >= 2 {
+			hasPair = true
+		}
+	}
+	if hasPair {
+		shanten--
+	}
+	return shanten
+}
+
+// Shanten returns how many tiles the hand is away from a ready hand.
+// Melded sets and kongs count as sets; the other tiles may still be rearranged.
+func Shanten(hand *Hand) int {
+	concealed, fixed := splitHand(hand)
+	return ShantenTiles(concealed, len(fixed))
+}
@@ -0,0 +1,64 @@
+package score
+
+import (
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+)
+
+type ShantenTestSuite struct{}
+
+var _ = check.Suite(&ShantenTestSuite{})
+
+func (s *ShantenTestSuite) TestShantenTiles(c *check.C) {
+	assertShanten := func(expected int, notation string) {
+		assert.Equal(c, expected, ShantenTiles(mustParseTiles(c, notation), 0), notation)
+	}
+
+	// Winning hands.
+	assertShanten(-1, "123b456b789b11c234c")
+	assertShanten(-1, "111b222b333b44c55c5c")
+	assertShanten(-1, "19b19c19sESWNrgwr")
+
+	// Ready hands.
+	assertShanten(0, "123b456b789b11c23c")
+	assertShanten(0, "123b456b789b234c1c")
+	assertShanten(0, "19b19c19sESWNrg1b")
+	assertShanten(0, "19b19c19sESWNrgw")
+	assertShanten(0, "1112345678999b")
+
+	// Further away.
+	assertShanten(1, "123b456b789b1c3c5c")
+	assertShanten(3, "123b456b79b1c5cEN")
+	assertShanten(2, "19b19c19sESWNr5c5c")
+	assertShanten(7, "147b258c369sESWN")
+}
+
+func (s *ShantenTestSuite) TestShantenMelded(c *check.C) {
+	assert.Equal(c, 0, ShantenTiles(mustParseTiles(c, "1b"), 4))
+	assert.Equal(c, -1, ShantenTiles(mustParseTiles(c, "11b"), 4))
+	assert.Equal(c, 2, ShantenTiles(mustParseTiles(c, "1b5c9sE"), 3))
+	// Thirteen orphans can't have melded sets.
+	assert.Equal(c, 6, ShantenTiles(mustParseTiles(c, "19b19cESW"), 1))
+}
+
+func (s *ShantenTestSuite) TestShanten(c *check.C) {
+	assert.Equal(c, 0, Shanten(mustParseHand(c, "123b 456b 11c 2c 3c | 789b")))
+	assert.Equal(c, 0, Shanten(mustParseHand(c, "1111b 234c 567c 99s 78s")))
+	assert.Equal(c, -1, Shanten(mustParseHand(c, "123b 456b 789b rrr EE")))
+
+	// Flowers and seasons don't count.
+	assert.Equal(c, 0, Shanten(mustParseHand(c, "123b 456b 789b 11c 23c 1f2t")))
+}
+
+func (s *ShantenTestSuite) TestShantenTooManyTiles(c *check.C) {
+	err := CheckTileCounts(mustParseTiles(c, "22222b"))
+	if assert.NotNil(c, err) {
+		assert.Contains(c, err.Error(), ErrTooManyTiles.Error())
+	}
+	assert.Nil(c, CheckTileCounts(mustParseTiles(c, "2222b 2c")))
+
+	// A fifth tile is left out, and doesn't leave its mark on other hands.
+	assert.Equal(c, -1, ShantenTiles(mustParseTiles(c, "22222b234c567c789s11s"), 0))
+	shapesOf([]int{0, 5, 0, 0, 0, 0, 0, 0, 0}, true)
+	assert.Equal(c, 0, ShantenTiles(mustParseTiles(c, "1b234c567c789s123s"), 0))
+}
@@ -0,0 +1,65 @@
+/*
+ * Detection of ready hands, and the tiles they are waiting for.
+ */
+
+package score
+
+// Wait is a tile that completes a hand, along with the best-scoring completed hand.
+type Wait struct {
+	Tile  Tile `json:"tile"`
+	Score int  `json:"score"`
+	Hand  Hand `json:"hand"`
+}
+
+// splitHand separates the sets that are fixed from the tiles that can still be
+// rearranged. Melded sets and kongs are fixed; the other concealed tiles are not.
+func splitHand(hand *Hand) ([]Tile, []Set) {
+	concealed := []Tile{}
+	fixed := []Set{}
+	for idx := range hand.Sets {
+		set := &hand.Sets[idx]
+		valid, _ := set.IsValid()
+		if valid && (!set.Concealed || len(set.Tiles) == 4) {
+			fixed = append(fixed, *set)
+			continue
+		}
+		concealed = append(concealed, set.Tiles...)
+	}
+	return concealed, fixed
+}
+
+// Waits returns every tile that completes the hand into a winning hand, using
+// the default rule set. See RuleSet.Waits().
+func Waits(hand *Hand) []Wait {
+	return DefaultRuleSet().Waits(hand)
+}
+
+// Waits returns every tile that completes the hand into a winning hand, in tile order.
+// A hand that isn't ready has no waits.
+func (rules *RuleSet) Waits(hand *Hand) []Wait {
+	concealed, fixed := splitHand(hand)
+
+	inHand := map[Tile]int{}
+	for _, tile := range allTiles(hand) {
+		inHand[tile]++
+	}
+
+	conditions := *hand
+	conditions.Sets = nil
+	waits := []Wait{}
+	for _, tile := range PlayingTiles() {
+		// There are only four of each tile.
+		if inHand[tile] >= 4 {
+			continue
+		}
+
+		completed := append(append([]Tile{}, concealed...), tile)
+		interpretations, err := rules.Interpret(completed, fixed, conditions)
+		if err != nil {
+			continue
+		}
+		best := interpretations[0]
+		waits = append(waits, Wait{tile, best.Score, best.Hand})
+	}
+	return waits
+}
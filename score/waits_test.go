@@ -0,0 +1,64 @@
+package score
+
+import (
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+)
+
+type WaitsTestSuite struct{}
+
+var _ = check.Suite(&WaitsTestSuite{})
+
+func waitTiles(waits []Wait) []Tile {
+	tiles := []Tile{}
+	for _, wait := range waits {
+		tiles = append(tiles, wait.Tile)
+	}
+	return tiles
+}
+
+func (s *WaitsTestSuite) TestWaits(c *check.C) {
+	// Two-sided wait on the chow.
+	waits := Waits(mustParseHand(c, "123b 456b 789b 11c 23c"))
+	assert.Equal(c, []Tile{Chars1, Chars4}, waitTiles(waits))
+	for _, wait := range waits {
+		assert.True(c, wait.Hand.Winning)
+		assert.Equal(c, Score(&wait.Hand), wait.Score)
+	}
+
+	// The concealed tiles may be rearranged, the melded sets may not.
+	waits = Waits(mustParseHand(c, "2345b 555c | 123s 789s"))
+	assert.Equal(c, []Tile{Balls2, Balls5}, waitTiles(waits))
+	waits = Waits(mustParseHand(c, "2b | 345b 123s 789s 555c"))
+	assert.Equal(c, []Tile{Balls2}, waitTiles(waits))
+
+	// Kongs stay kongs.
+	waits = Waits(mustParseHand(c, "1111b 234c 567c 99s 78s"))
+	assert.Equal(c, []Tile{Bamboo6, Bamboo9}, waitTiles(waits))
+
+	// There is no fifth tile to wait on.
+	waits = Waits(mustParseHand(c, "123b 456b 789b 111c 1c"))
+	assert.Empty(c, waits)
+
+	// Not ready at all.
+	waits = Waits(mustParseHand(c, "135b 79c EWN rgw 1s 5s"))
+	assert.Empty(c, waits)
+}
+
+func (s *WaitsTestSuite) TestWaitsThirteenOrphans(c *check.C) {
+	waits := Waits(mustParseHand(c, "19b 19c 19s ESWN rgw"))
+	assert.Equal(c, orphans, waitTiles(waits))
+	for _, wait := range waits {
+		assert.Equal(c, DefaultRuleSet().Limit, wait.Score)
+	}
+}
+
+func (s *WaitsTestSuite) TestWaitsKeepConditions(c *check.C) {
+	hand := mustParseHand(c, "123b 456b 789b rr 2c 3c @EE")
+	hand.WinSelfDrawn = true
+	waits := Waits(hand)
+	if assert.Len(c, waits, 2) {
+		assert.Equal(c, WindEast, waits[0].Hand.WindOwn)
+		assert.True(c, waits[0].Hand.WinSelfDrawn)
+	}
+}
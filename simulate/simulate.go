@@ -0,0 +1,220 @@
+/*
+ * Self-play of bots, to see how a rule set works out in practice.
+ *
+ * Every hand is played by four bots on its own seeded wall, so that a
+ * simulation gives the same statistics every time it's run with the same seed,
+ * no matter how many hands are played in parallel.
+ */
+
+package simulate
+
+import (
+	"errors"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/sybrenstuvel/mahjong/bot"
+	"github.com/sybrenstuvel/mahjong/game"
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/wall"
+)
+
+// ErrNoHands is returned when asked to simulate less than one hand.
+var ErrNoHands = errors.New("at least one hand must be played")
+
+// Options determine what is simulated.
+type Options struct {
+	Rules   *score.RuleSet
+	Hands   int
+	Seed    int64 // Hand N is played on the wall of seed Seed+N.
+	Workers int   // Number of hands played in parallel; 0 uses all CPUs.
+	Bots    [score.NumPlayers]bot.Difficulty
+}
+
+// Bucket counts the winning hands with a score up to and including Max.
+type Bucket struct {
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+// Stats are the outcome of a simulation.
+type Stats struct {
+	RuleSet    string                `json:"ruleset"`
+	Hands      int                   `json:"hands"`
+	Wins       int                   `json:"wins"`
+	SelfDrawn  int                   `json:"self_drawn"`
+	Draws      int                   `json:"draws"` // Hands that ended without a winner.
+	TotalScore int                   `json:"total_score"`
+	MaxScore   int                   `json:"max_score"`
+	Detectors  map[string]int        `json:"detectors"`   // How many winning hands each detector gave doubles.
+	Points     map[string]int        `json:"points"`      // How many winning hands each points detector gave points.
+	LimitHands map[string]int        `json:"limit_hands"` // How many winning hands were each limit hand.
+	SeatWins   [score.NumPlayers]int `json:"seat_wins"`
+	Scores     []int                 `json:"-"` // The score of every winning hand, for the distribution.
+}
+
+func newStats(ruleSet string) *Stats {
+	return &Stats{
+		RuleSet:    ruleSet,
+		Detectors:  map[string]int{},
+		Points:     map[string]int{},
+		LimitHands: map[string]int{},
+		Scores:     []int{},
+	}
+}
+
+// DrawRate returns the fraction of hands that ended without a winner.
+func (s *Stats) DrawRate() float64 {
+	if s.Hands == 0 {
+		return 0
+	}
+	return float64(s.Draws) / float64(s.Hands)
+}
+
+// AverageScore returns the average score of the winning hands.
+func (s *Stats) AverageScore() float64 {
+	if s.Wins == 0 {
+		return 0
+	}
+	return float64(s.TotalScore) / float64(s.Wins)
+}
+
+// Frequency returns the fraction of winning hands that had the detector fire.
+func (s *Stats) Frequency(detector string) float64 {
+	if s.Wins == 0 {
+		return 0
+	}
+	return float64(s.Detectors[detector]) / float64(s.Wins)
+}
+
+// Distribution counts the scores of winning hands in buckets that double in
+// size, starting at the given maximum score of the first bucket. Empty buckets
+// after the highest score are left out.
+func (s *Stats) Distribution(first int) []Bucket {
+	if first < 1 {
+		first = 1
+	}
+	sorted := append([]int{}, s.Scores...)
+	sort.Ints(sorted)
+
+	buckets := []Bucket{}
+	bucket := Bucket{Max: first}
+	for _, handScore := range sorted {
+		for handScore > bucket.Max {
+			buckets = append(buckets, bucket)
+			bucket = Bucket{Max: bucket.Max * 2}
+		}
+		bucket.Count++
+	}
+	return append(buckets, bucket)
+}
+
+// add records the result of one hand.
+func (s *Stats) add(result *game.Result) {
+	s.Hands++
+	if result.Winner < 0 {
+		s.Draws++
+		return
+	}
+
+	s.Wins++
+	s.SeatWins[result.Winner]++
+	if result.Discarder < 0 {
+		s.SelfDrawn++
+	}
+
+	breakdown := result.Breakdown
+	s.TotalScore += breakdown.Score
+	if breakdown.Score > s.MaxScore {
+		s.MaxScore = breakdown.Score
+	}
+	s.Scores = append(s.Scores, breakdown.Score)
+	for _, detector := range breakdown.Detectors {
+		s.Detectors[detector.Name]++
+	}
+	for _, points := range breakdown.Points {
+		s.Points[points.Name]++
+	}
+	for _, limitHand := range breakdown.LimitHands {
+		s.LimitHands[limitHand]++
+	}
+}
+
+// merge adds the statistics of another simulation of the same rule set.
+func (s *Stats) merge(other *Stats) {
+	s.Hands += other.Hands
+	s.Wins += other.Wins
+	s.SelfDrawn += other.SelfDrawn
+	s.Draws += other.Draws
+	s.TotalScore += other.TotalScore
+	if other.MaxScore > s.MaxScore {
+		s.MaxScore = other.MaxScore
+	}
+	for seat, wins := range other.SeatWins {
+		s.SeatWins[seat] += wins
+	}
+	s.Scores = append(s.Scores, other.Scores...)
+	for name, count := range other.Detectors {
+		s.Detectors[name] += count
+	}
+	for name, count := range other.Points {
+		s.Points[name] += count
+	}
+	for name, count := range other.LimitHands {
+		s.LimitHands[name] += count
+	}
+}
+
+// PlayHand lets four bots play the hand with the given number. The dealer moves
+// around the table from hand to hand; the round wind is always East.
+func PlayHand(options Options, number int) *game.Result {
+	seed := options.Seed + int64(number)
+	g := game.New(options.Rules, wall.New(seed), number%score.NumPlayers, score.WindEast)
+	for seat, difficulty := range options.Bots {
+		// Every bot gets its own seed, so that two bots of the same difficulty play differently.
+		g.SetBot(seat, bot.New(difficulty, seed*score.NumPlayers+int64(seat)))
+	}
+	return g.Result()
+}
+
+// Run plays the hands, and returns the statistics.
+func Run(options Options) (*Stats, error) {
+	if options.Hands < 1 {
+		return nil, ErrNoHands
+	}
+	workers := options.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	numbers := make(chan int)
+	go func() {
+		for number := 0; number < options.Hands; number++ {
+			numbers <- number
+		}
+		close(numbers)
+	}()
+
+	total := newStats(options.Rules.Name)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats := newStats(options.Rules.Name)
+			for number := range numbers {
+				stats.add(PlayHand(options, number))
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			total.merge(stats)
+		}()
+	}
+	wg.Wait()
+
+	sort.Ints(total.Scores)
+	return total, nil
+}
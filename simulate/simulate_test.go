@@ -0,0 +1,75 @@
+package simulate
+
+import (
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+
+	"github.com/sybrenstuvel/mahjong/bot"
+	"github.com/sybrenstuvel/mahjong/game"
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+type SimulateTestSuite struct{}
+
+var _ = check.Suite(&SimulateTestSuite{})
+
+func options(hands, workers int) Options {
+	return Options{
+		Rules:   score.DefaultRuleSet(),
+		Hands:   hands,
+		Seed:    1,
+		Workers: workers,
+		Bots:    [score.NumPlayers]bot.Difficulty{bot.Normal, bot.Normal, bot.Normal, bot.Normal},
+	}
+}
+
+func (s *SimulateTestSuite) TestRun(c *check.C) {
+	stats, err := Run(options(40, 4))
+	if !assert.Nil(c, err) {
+		c.FailNow()
+	}
+
+	assert.Equal(c, 40, stats.Hands)
+	assert.Equal(c, stats.Hands, stats.Wins+stats.Draws)
+	assert.Len(c, stats.Scores, stats.Wins)
+	assert.True(c, stats.Wins > 0)
+	assert.Equal(c, stats.Wins, stats.SeatWins[0]+stats.SeatWins[1]+stats.SeatWins[2]+stats.SeatWins[3])
+
+	total := 0
+	for _, bucket := range stats.Distribution(16) {
+		total += bucket.Count
+	}
+	assert.Equal(c, stats.Wins, total)
+}
+
+func (s *SimulateTestSuite) TestReproducible(c *check.C) {
+	serial, err := Run(options(20, 1))
+	assert.Nil(c, err)
+	parallel, err := Run(options(20, 3))
+	assert.Nil(c, err)
+	assert.Equal(c, serial, parallel)
+}
+
+func (s *SimulateTestSuite) TestNoHands(c *check.C) {
+	_, err := Run(options(0, 1))
+	assert.Equal(c, ErrNoHands, err)
+}
+
+func (s *SimulateTestSuite) TestStats(c *check.C) {
+	stats := newStats("test")
+	stats.add(&game.Result{Winner: -1, Discarder: -1})
+	stats.add(&game.Result{Winner: 2, Discarder: -1, Breakdown: &score.Breakdown{
+		Score:     40,
+		Detectors: []score.DetectorScore{{Name: "full flush", Doubles: 2}},
+	}})
+	stats.add(&game.Result{Winner: 1, Discarder: 3, Breakdown: &score.Breakdown{Score: 8}})
+
+	assert.Equal(c, 3, stats.Hands)
+	assert.Equal(c, 2, stats.Wins)
+	assert.Equal(c, 1, stats.SelfDrawn)
+	assert.InDelta(c, 1.0/3.0, stats.DrawRate(), 0.001)
+	assert.InDelta(c, 24.0, stats.AverageScore(), 0.001)
+	assert.InDelta(c, 0.5, stats.Frequency("full flush"), 0.001)
+	assert.Equal(c, 40, stats.MaxScore)
+	assert.Equal(c, []Bucket{{16, 1}, {32, 0}, {64, 1}}, stats.Distribution(16))
+}
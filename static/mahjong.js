@@ -3,13 +3,42 @@ $(function() {
     toastr.options.progressBar = true;
     toastr.options.positionClass = 'toast-bottom-left';
     toastr.options.hideMethod = 'slideUp';
+
+    load_rulesets();
+    init_builder();
 })
 
+function load_rulesets() {
+    var $select = $('#ruleset');
+    if (!$select.length) return;
+
+    $.get('/api/rulesets')
+    .done(function(rulesets) {
+        $.each(rulesets, function(idx, ruleset) {
+            $('<option>')
+                .val(ruleset.name)
+                .text(ruleset.name + ' — ' + ruleset.description)
+                .prop('selected', ruleset.name == $select.data('default'))
+                .appendTo($select);
+        });
+    })
+    .fail(function(err) {
+        toastr.error(err.statusText, 'Unable to get rule sets');
+    })
+    ;
+}
+
 function random_hand() {
-    $.get('/api/random')
-    .done(function(data) {
-        console.log('hand randomised', data);
-        $('#json_input').val(JSON.stringify(data, undefined, 4));
+    $.get('/api/random', {
+        kind: $('#random_kind').val() || 'winning',
+        flush: $('#random_flush').is(':checked'),
+        flowers: $('#random_flowers').is(':checked'),
+    })
+    .done(function(data, status, xhr) {
+        var seed = xhr.getResponseHeader('X-Seed');
+        console.log('hand randomised with seed', seed, data);
+        $('#random_seed').text('Seed ' + seed);
+        builder_load(data);
     })
     .fail(function(err) {
         toastr.error(err.statusText, 'Unable to get random hand');
@@ -17,14 +46,305 @@ function random_hand() {
     ;
 }
 
+// Returns the hand to send to the server, either in notation or as JSON.
+function entered_hand() {
+    var hand = $('#notation_input').val().trim();
+    if (!hand) hand = $('#json_input').val();
+    return hand;
+}
+
+// Returns the URL of the API endpoint, with the chosen rule set and limit.
+function scoring_url(endpoint) {
+    var url = endpoint + '?ruleset=' + encodeURIComponent($('#ruleset').val() || '');
+    var limit = $('#limit').val();
+    if (limit !== '') url += '&limit=' + encodeURIComponent(limit);
+    return url;
+}
+
 function score_hand() {
-    $.post('/api/calc-score', json=$('#json_input').val())
+    $.post(scoring_url('/api/calc-score'), entered_hand())
     .done(function(data) {
         console.log('hand scored', data);
         toastr.success(data.score, 'Calculated score');
+        show_breakdown(data.breakdown);
     })
     .fail(function(err) {
-        toastr.error(err.statusText, 'Unable to score hand');
+        toastr.error(err.responseText || err.statusText, 'Unable to score hand');
     })
     ;
 }
+
+function show_waits() {
+    $.post(scoring_url('/api/waits'), entered_hand())
+    .done(function(waits) {
+        console.log('waits found', waits);
+        var $waits = $('#waits').empty();
+        if (!waits.length) {
+            $('<p>').text('This hand is not ready; no single tile completes it.').appendTo($waits);
+            return;
+        }
+
+        var $tbody = $('<tbody>');
+        $.each(waits, function(idx, wait) {
+            var sets = $.map(wait.hand.sets, function(set) { return tile_notation(set.tiles); });
+            $('<tr>')
+                .append($('<td>').text(tile_notation([wait.tile])))
+                .append($('<td>').text(sets.join(' ')))
+                .append($('<td>').text(wait.score))
+                .appendTo($tbody);
+        });
+        $('<table class="table waits">')
+            .append('<thead><tr><th>Waiting on</th><th>Completed hand</th><th>Score</th></tr></thead>')
+            .append($tbody)
+            .appendTo($waits);
+    })
+    .fail(function(err) {
+        toastr.error(err.responseText || err.statusText, 'Unable to find waits');
+    })
+    ;
+}
+
+function tile_notation(tiles) {
+    var honours = {41: 'E', 42: 'S', 43: 'W', 44: 'N', 51: 'r', 52: 'g', 53: 'w'};
+    var suits = {1: 'b', 2: 'c', 3: 's', 6: 'f', 7: 't'};
+    var text = '';
+    $.each(tiles, function(idx, tile) {
+        if (honours[tile]) {
+            text += honours[tile];
+            return;
+        }
+        var suit = suits[Math.floor(tile / 10)];
+        text += tile % 10;
+        if (idx == tiles.length - 1 || suits[Math.floor(tiles[idx + 1] / 10)] != suit) {
+            text += suit;
+        }
+    });
+    return text;
+}
+
+function show_breakdown(breakdown) {
+    var $tbody = $('<tbody>');
+    var add_row = function(label, points, doubles) {
+        $('<tr>')
+            .append($('<td>').text(label))
+            .append($('<td>').text(points || ''))
+            .append($('<td>').text(doubles || ''))
+            .appendTo($tbody);
+    };
+
+    $.each(breakdown.sets, function(idx, set) {
+        var label = tile_notation(set.tiles) + ' (' + (set.concealed ? 'concealed ' : '') + set.type + ')';
+        if (!set.valid) label = tile_notation(set.tiles) + ' (not a valid set)';
+        add_row(label, set.points, set.doubles);
+    });
+    if (breakdown.winning) add_row('Winning bonus', breakdown.winning_bonus);
+    if (breakdown.bonus_tiles.length) {
+        add_row('Flowers & seasons ' + tile_notation(breakdown.bonus_tiles), breakdown.bonus_points);
+    }
+    $.each(breakdown.points, function(idx, points) {
+        add_row(points.name, points.points);
+    });
+    $.each(breakdown.detectors, function(idx, detector) {
+        add_row(detector.name, null, detector.doubles);
+    });
+
+    var $tfoot = $('<tfoot>');
+    $('<tr>')
+        .append($('<th>').text('Total'))
+        .append($('<th>').text(breakdown.basic_score))
+        .append($('<th>').text(breakdown.doubles))
+        .appendTo($tfoot);
+    var derivation = breakdown.basic_score + ' × 2^' + breakdown.doubles + ' = ' + breakdown.score;
+    if (breakdown.limit_hands.length) {
+        derivation = 'Limit hand: ' + breakdown.limit_hands.join(', ') + ' = ' + breakdown.score;
+    } else if (breakdown.limit_reached) {
+        derivation = breakdown.basic_score + ' × 2^' + breakdown.doubles + ' → limit reached: ' + breakdown.score;
+    }
+    $('<tr>')
+        .append($('<th>').text('Score'))
+        .append($('<th colspan=2>').text(derivation))
+        .appendTo($tfoot);
+
+    $('<table class="table breakdown">')
+        .append('<thead><tr><th>Item</th><th>Points</th><th>Doubles</th></tr></thead>')
+        .append($tbody)
+        .append($tfoot)
+        .appendTo($('#breakdown').empty());
+}
+
+// The hand that is being built by clicking tiles.
+var builder = {
+    sets: [],     // Each set is {tiles: [...], concealed: bool}.
+    bonus: [],    // Flowers and seasons.
+    current: [],  // Tiles of the set that is being composed.
+    request: 0,   // Number of the latest live scoring request, to ignore stale replies.
+};
+
+// Returns the Unicode mahjong glyph of the tile.
+function tile_glyph(tile) {
+    var suit = Math.floor(tile / 10), rank = tile % 10;
+    var offsets = {
+        1: 0x1F019 - 1,  // Balls, or circles.
+        2: 0x1F007 - 1,  // Characters.
+        3: 0x1F010 - 1,  // Bamboo.
+        4: 0x1F000 - 1,  // Winds, in the order east, south, west, north.
+        5: 0x1F004 - 1,  // Dragons, in the order red, green, white.
+        6: 0x1F022 - 1,  // Flowers.
+        7: 0x1F026 - 1,  // Seasons.
+    };
+    return String.fromCodePoint(offsets[suit] + rank);
+}
+
+function tile_span(tile) {
+    return $('<span class="tile mj">').text(tile_glyph(tile)).attr('title', tile_notation([tile]));
+}
+
+function init_builder() {
+    var $palette = $('#palette');
+    if (!$palette.length) return;
+
+    var rows = [
+        [11, 12, 13, 14, 15, 16, 17, 18, 19],
+        [21, 22, 23, 24, 25, 26, 27, 28, 29],
+        [31, 32, 33, 34, 35, 36, 37, 38, 39],
+        [41, 42, 43, 44, 51, 52, 53],
+        [61, 62, 63, 64, 71, 72, 73, 74],
+    ];
+    $.each(rows, function(idx, row) {
+        var $row = $('<div class="palette-row">').appendTo($palette);
+        $.each(row, function(idx, tile) {
+            $('<button type="button" class="tile mj">')
+                .text(tile_glyph(tile))
+                .attr('title', tile_notation([tile]))
+                .click(function() { builder_click(tile); })
+                .appendTo($row);
+        });
+    });
+
+    $('.builder-option, #ruleset, #limit').on('change', builder_changed);
+}
+
+function builder_click(tile) {
+    if (tile > 60) {
+        builder.bonus.push(tile);
+        builder_changed();
+        return;
+    }
+    if (builder.current.length >= 4) {
+        toastr.warning('A set has at most four tiles.', 'Set is full');
+        return;
+    }
+    builder.current.push(tile);
+    render_current_set();
+}
+
+function render_current_set() {
+    var $current = $('#current_set').empty();
+    $.each(builder.current, function(idx, tile) {
+        tile_span(tile).appendTo($current);
+    });
+}
+
+function builder_add_set() {
+    if (!builder.current.length) return;
+    builder.sets.push({
+        tiles: builder.current.slice().sort(function(a, b) { return a - b; }),
+        concealed: $('#current_concealed').is(':checked'),
+    });
+    builder_clear_set();
+    builder_changed();
+}
+
+function builder_clear_set() {
+    builder.current = [];
+    render_current_set();
+}
+
+function render_built_hand() {
+    var $hand = $('#built_hand').empty();
+    var remove_button = function(list, idx) {
+        return $('<button type="button" class="remove" title="Remove">&times;</button>')
+            .click(function(event) {
+                event.stopPropagation();
+                list.splice(idx, 1);
+                builder_changed();
+            });
+    };
+
+    $.each(builder.sets, function(idx, set) {
+        var $set = $('<span class="set">')
+            .toggleClass('concealed', set.concealed)
+            .attr('title', set.concealed ? 'Concealed; click to meld' : 'Melded; click to conceal')
+            .click(function() {
+                set.concealed = !set.concealed;
+                builder_changed();
+            })
+            .appendTo($hand);
+        $.each(set.tiles, function(idx, tile) {
+            $('<span class="tile mj">').text(tile_glyph(tile)).appendTo($set);
+        });
+        remove_button(builder.sets, idx).appendTo($set);
+    });
+    if (builder.bonus.length) {
+        var $bonus = $('<span class="set bonus">').appendTo($hand);
+        $.each(builder.bonus, function(idx, tile) {
+            tile_span(tile).appendTo($bonus);
+            remove_button(builder.bonus, idx).appendTo($bonus);
+        });
+    }
+}
+
+// Returns the built hand as a hand document.
+function builder_hand() {
+    var hand = {
+        sets: builder.sets,
+        bonus: builder.bonus,
+        wind_own: parseInt($('#wind_own').val()),
+        wind_round: parseInt($('#wind_round').val()),
+    };
+    $('.win-conditions input[data-flag]').each(function() {
+        hand[$(this).data('flag')] = $(this).is(':checked');
+    });
+    return hand;
+}
+
+// Shows the built hand, and scores it without recording it.
+function builder_changed() {
+    render_built_hand();
+    if (!builder.sets.length && !builder.bonus.length) {
+        $('#live_score').text('');
+        return;
+    }
+
+    var hand = JSON.stringify(builder_hand(), undefined, 4);
+    $('#notation_input').val('');
+    $('#json_input').val(hand);
+
+    var request = ++builder.request;
+    $.post(scoring_url('/api/calc-score') + '&record=false', hand)
+    .done(function(data) {
+        if (request != builder.request) return;
+        $('#live_score').text('Score: ' + data.score + (data.breakdown.winning ? ' (winning hand)' : ''));
+        show_breakdown(data.breakdown);
+    })
+    .fail(function(err) {
+        if (request != builder.request) return;
+        $('#live_score').text(err.responseText || err.statusText);
+    })
+    ;
+}
+
+// Loads a hand document into the builder.
+function builder_load(hand) {
+    builder.sets = $.map(hand.sets || [], function(set) {
+        return {tiles: set.tiles.slice(), concealed: set.concealed};
+    });
+    builder.bonus = (hand.bonus || []).slice();
+    builder_clear_set();
+    if (hand.wind_own) $('#wind_own').val(hand.wind_own);
+    if (hand.wind_round) $('#wind_round').val(hand.wind_round);
+    $('.win-conditions input[data-flag]').each(function() {
+        $(this).prop('checked', !!hand[$(this).data('flag')]);
+    });
+    builder_changed();
+}
@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketPlayers     = []byte("players")
+	bucketSessions    = []byte("sessions")
+	bucketHands       = []byte("hands")
+	bucketSettlements = []byte("settlements")
+	bucketTournaments = []byte("tournaments")
+)
+
+// Bolt stores everything in a BoltDB file, as JSON documents.
+type Bolt struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens the database file, creating it when it doesn't exist yet.
+func OpenBolt(filename string) (*Bolt, error) {
+	db, err := bolt.Open(filename, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		buckets := [][]byte{bucketPlayers, bucketSessions, bucketHands, bucketSettlements, bucketTournaments}
+		for _, name := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Bolt{db}, nil
+}
+
+func key(id ID) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(id))
+	return key
+}
+
+// add stores the document under the next ID of the bucket. The ID is set with
+// setID before the document is stored.
+func (b *Bolt) add(bucket []byte, setID func(ID), document interface{}) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucket)
+		sequence, err := bkt.NextSequence()
+		if err != nil {
+			return err
+		}
+		setID(ID(sequence))
+		data, err := json.Marshal(document)
+		if err != nil {
+			return err
+		}
+		return bkt.Put(key(ID(sequence)), data)
+	})
+}
+
+// update replaces the existing document with the given ID.
+func (b *Bolt) update(bucket []byte, kind string, id ID, document interface{}) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucket)
+		if bkt.Get(key(id)) == nil {
+			return notFound(kind, id)
+		}
+		data, err := json.Marshal(document)
+		if err != nil {
+			return err
+		}
+		return bkt.Put(key(id), data)
+	})
+}
+
+// get reads the document with the given ID.
+func (b *Bolt) get(bucket []byte, kind string, id ID, document interface{}) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get(key(id))
+		if data == nil {
+			return notFound(kind, id)
+		}
+		return json.Unmarshal(data, document)
+	})
+}
+
+// each calls the function with the JSON of every document in the bucket, in order of ID.
+func (b *Bolt) each(bucket []byte, fn func(data []byte) error) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(_, data []byte) error {
+			return fn(data)
+		})
+	})
+}
+
+// AddPlayer stores the player.
+func (b *Bolt) AddPlayer(player *Player) error {
+	if player.Name == "" {
+		return ErrNoName
+	}
+	created(&player.Created)
+	return b.add(bucketPlayers, func(id ID) { player.ID = id }, player)
+}
+
+// Player returns the player with the given ID.
+func (b *Bolt) Player(id ID) (*Player, error) {
+	player := Player{}
+	if err := b.get(bucketPlayers, "player", id, &player); err != nil {
+		return nil, err
+	}
+	return &player, nil
+}
+
+// Players returns all players.
+func (b *Bolt) Players() ([]Player, error) {
+	players := []Player{}
+	err := b.each(bucketPlayers, func(data []byte) error {
+		player := Player{}
+		if err := json.Unmarshal(data, &player); err != nil {
+			return err
+		}
+		players = append(players, player)
+		return nil
+	})
+	return players, err
+}
+
+// AddSession stores the session.
+func (b *Bolt) AddSession(session *Session) error {
+	if err := validateSession(b, session); err != nil {
+		return err
+	}
+	created(&session.Created)
+	return b.add(bucketSessions, func(id ID) { session.ID = id }, session)
+}
+
+// Session returns the session with the given ID.
+func (b *Bolt) Session(id ID) (*Session, error) {
+	session := Session{}
+	if err := b.get(bucketSessions, "session", id, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Sessions returns all sessions.
+func (b *Bolt) Sessions() ([]Session, error) {
+	sessions := []Session{}
+	err := b.each(bucketSessions, func(data []byte) error {
+		session := Session{}
+		if err := json.Unmarshal(data, &session); err != nil {
+			return err
+		}
+		sessions = append(sessions, session)
+		return nil
+	})
+	return sessions, err
+}
+
+// AddHand stores the scored hand.
+func (b *Bolt) AddHand(hand *Hand) error {
+	created(&hand.Created)
+	return b.add(bucketHands, func(id ID) { hand.ID = id }, hand)
+}
+
+// UpdateHand replaces the stored hand with the same ID.
+func (b *Bolt) UpdateHand(hand *Hand) error {
+	return b.update(bucketHands, "hand", hand.ID, hand)
+}
+
+// Hand returns the hand with the given ID.
+func (b *Bolt) Hand(id ID) (*Hand, error) {
+	hand := Hand{}
+	if err := b.get(bucketHands, "hand", id, &hand); err != nil {
+		return nil, err
+	}
+	return &hand, nil
+}
+
+// Hands returns the hands that pass the filter.
+func (b *Bolt) Hands(filter HandFilter) ([]Hand, error) {
+	hands := []Hand{}
+	err := b.each(bucketHands, func(data []byte) error {
+		hand := Hand{}
+		if err := json.Unmarshal(data, &hand); err != nil {
+			return err
+		}
+		if filter.Matches(&hand) {
+			hands = append(hands, hand)
+		}
+		return nil
+	})
+	return hands, err
+}
+
+// AddSettlement stores the settlement.
+func (b *Bolt) AddSettlement(settlement *Settlement) error {
+	created(&settlement.Created)
+	return b.add(bucketSettlements, func(id ID) { settlement.ID = id }, settlement)
+}
+
+// UpdateSettlement replaces the stored settlement with the same ID.
+func (b *Bolt) UpdateSettlement(settlement *Settlement) error {
+	return b.update(bucketSettlements, "settlement", settlement.ID, settlement)
+}
+
+// Settlements returns the settlements of the session, or all of them for session 0.
+func (b *Bolt) Settlements(session ID) ([]Settlement, error) {
+	settlements := []Settlement{}
+	err := b.each(bucketSettlements, func(data []byte) error {
+		settlement := Settlement{}
+		if err := json.Unmarshal(data, &settlement); err != nil {
+			return err
+		}
+		if session == 0 || settlement.Session == session {
+			settlements = append(settlements, settlement)
+		}
+		return nil
+	})
+	return settlements, err
+}
+
+// AddTournament stores the tournament.
+func (b *Bolt) AddTournament(tournament *Tournament) error {
+	if err := validateTournament(b, tournament); err != nil {
+		return err
+	}
+	created(&tournament.Created)
+	return b.add(bucketTournaments, func(id ID) { tournament.ID = id }, tournament)
+}
+
+// UpdateTournament replaces the stored tournament with the same ID.
+func (b *Bolt) UpdateTournament(tournament *Tournament) error {
+	if err := validateTournament(b, tournament); err != nil {
+		return err
+	}
+	return b.update(bucketTournaments, "tournament", tournament.ID, tournament)
+}
+
+// Tournament returns the tournament with the given ID.
+func (b *Bolt) Tournament(id ID) (*Tournament, error) {
+	tournament := Tournament{}
+	if err := b.get(bucketTournaments, "tournament", id, &tournament); err != nil {
+		return nil, err
+	}
+	return &tournament, nil
+}
+
+// Tournaments returns all tournaments.
+func (b *Bolt) Tournaments() ([]Tournament, error) {
+	tournaments := []Tournament{}
+	err := b.each(bucketTournaments, func(data []byte) error {
+		tournament := Tournament{}
+		if err := json.Unmarshal(data, &tournament); err != nil {
+			return err
+		}
+		tournaments = append(tournaments, tournament)
+		return nil
+	})
+	return tournaments, err
+}
+
+// Close closes the database file.
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,266 @@
+package storage
+
+import (
+	"sync"
+	"time"
+)
+
+// Memory keeps everything in memory, and forgets it when the program stops.
+// It is meant for tests, and for running without a database.
+type Memory struct {
+	mutex       sync.RWMutex
+	players     []Player
+	sessions    []Session
+	hands       []Hand
+	settlements []Settlement
+	tournaments []Tournament
+}
+
+// NewMemory returns an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func created(t *time.Time) {
+	if t.IsZero() {
+		*t = time.Now().UTC()
+	}
+}
+
+// AddPlayer stores the player.
+func (m *Memory) AddPlayer(player *Player) error {
+	if player.Name == "" {
+		return ErrNoName
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	player.ID = ID(len(m.players) + 1)
+	created(&player.Created)
+	m.players = append(m.players, *player)
+	return nil
+}
+
+// Player returns the player with the given ID.
+func (m *Memory) Player(id ID) (*Player, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if id < 1 || int(id) > len(m.players) {
+		return nil, notFound("player", id)
+	}
+	player := m.players[id-1]
+	return &player, nil
+}
+
+// Players returns all players.
+func (m *Memory) Players() ([]Player, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return append([]Player{}, m.players...), nil
+}
+
+// AddSession stores the session.
+func (m *Memory) AddSession(session *Session) error {
+	if err := validateSession(m, session); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	session.ID = ID(len(m.sessions) + 1)
+	created(&session.Created)
+	stored := *session
+	stored.Players = append([]ID{}, session.Players...)
+	m.sessions = append(m.sessions, stored)
+	return nil
+}
+
+// Session returns the session with the given ID.
+func (m *Memory) Session(id ID) (*Session, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if id < 1 || int(id) > len(m.sessions) {
+		return nil, notFound("session", id)
+	}
+	session := m.sessions[id-1]
+	session.Players = append([]ID{}, session.Players...)
+	return &session, nil
+}
+
+// Sessions returns all sessions.
+func (m *Memory) Sessions() ([]Session, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	sessions := make([]Session, len(m.sessions))
+	for idx, session := range m.sessions {
+		session.Players = append([]ID{}, session.Players...)
+		sessions[idx] = session
+	}
+	return sessions, nil
+}
+
+// AddHand stores the scored hand.
+func (m *Memory) AddHand(hand *Hand) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	hand.ID = ID(len(m.hands) + 1)
+	created(&hand.Created)
+	m.hands = append(m.hands, *hand)
+	return nil
+}
+
+// UpdateHand replaces the stored hand with the same ID.
+func (m *Memory) UpdateHand(hand *Hand) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if hand.ID < 1 || int(hand.ID) > len(m.hands) {
+		return notFound("hand", hand.ID)
+	}
+	m.hands[hand.ID-1] = *hand
+	return nil
+}
+
+// Hand returns the hand with the given ID.
+func (m *Memory) Hand(id ID) (*Hand, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if id < 1 || int(id) > len(m.hands) {
+		return nil, notFound("hand", id)
+	}
+	hand := m.hands[id-1]
+	return &hand, nil
+}
+
+// Hands returns the hands that pass the filter.
+func (m *Memory) Hands(filter HandFilter) ([]Hand, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	hands := []Hand{}
+	for idx := range m.hands {
+		if filter.Matches(&m.hands[idx]) {
+			hands = append(hands, m.hands[idx])
+		}
+	}
+	return hands, nil
+}
+
+// AddSettlement stores the settlement.
+func (m *Memory) AddSettlement(settlement *Settlement) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	settlement.ID = ID(len(m.settlements) + 1)
+	created(&settlement.Created)
+	m.settlements = append(m.settlements, *settlement)
+	return nil
+}
+
+// UpdateSettlement replaces the stored settlement with the same ID.
+func (m *Memory) UpdateSettlement(settlement *Settlement) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if settlement.ID < 1 || int(settlement.ID) > len(m.settlements) {
+		return notFound("settlement", settlement.ID)
+	}
+	m.settlements[settlement.ID-1] = *settlement
+	return nil
+}
+
+// Settlements returns the settlements of the session, or all of them for session 0.
+func (m *Memory) Settlements(session ID) ([]Settlement, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	settlements := []Settlement{}
+	for _, settlement := range m.settlements {
+		if session == 0 || settlement.Session == session {
+			settlements = append(settlements, settlement)
+		}
+	}
+	return settlements, nil
+}
+
+// copyTournament returns a deep copy, so that changes to it don't affect the stored tournament.
+func copyTournament(tournament Tournament) Tournament {
+	tournament.Players = append([]ID{}, tournament.Players...)
+	rounds := make([]Round, len(tournament.Rounds))
+	for idx, round := range tournament.Rounds {
+		rounds[idx].Byes = append([]ID{}, round.Byes...)
+		rounds[idx].Tables = make([]Table, len(round.Tables))
+		for tableIdx, table := range round.Tables {
+			if table.Scores != nil {
+				scores := *table.Scores
+				table.Scores = &scores
+			}
+			rounds[idx].Tables[tableIdx] = table
+		}
+	}
+	tournament.Rounds = rounds
+	return tournament
+}
+
+// AddTournament stores the tournament.
+func (m *Memory) AddTournament(tournament *Tournament) error {
+	if err := validateTournament(m, tournament); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	tournament.ID = ID(len(m.tournaments) + 1)
+	created(&tournament.Created)
+	m.tournaments = append(m.tournaments, copyTournament(*tournament))
+	return nil
+}
+
+// UpdateTournament replaces the stored tournament with the same ID.
+func (m *Memory) UpdateTournament(tournament *Tournament) error {
+	if err := validateTournament(m, tournament); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if tournament.ID < 1 || int(tournament.ID) > len(m.tournaments) {
+		return notFound("tournament", tournament.ID)
+	}
+	m.tournaments[tournament.ID-1] = copyTournament(*tournament)
+	return nil
+}
+
+// Tournament returns the tournament with the given ID.
+func (m *Memory) Tournament(id ID) (*Tournament, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if id < 1 || int(id) > len(m.tournaments) {
+		return nil, notFound("tournament", id)
+	}
+	tournament := copyTournament(m.tournaments[id-1])
+	return &tournament, nil
+}
+
+// Tournaments returns all tournaments.
+func (m *Memory) Tournaments() ([]Tournament, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	tournaments := make([]Tournament, len(m.tournaments))
+	for idx, tournament := range m.tournaments {
+		tournaments[idx] = copyTournament(tournament)
+	}
+	return tournaments, nil
+}
+
+// Close does nothing, as there is nothing to close.
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"sort"
+
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+// WinRate counts how many of the hands were won.
+type WinRate struct {
+	Hands int     `json:"hands"`
+	Wins  int     `json:"wins"`
+	Rate  float64 `json:"rate"` // Wins divided by hands, or 0 without hands.
+}
+
+func (r *WinRate) add(won bool) {
+	r.Hands++
+	if won {
+		r.Wins++
+	}
+	r.Rate = float64(r.Wins) / float64(r.Hands)
+}
+
+// Pattern counts how often a scoring pattern, like a double or a limit hand, was in a winning hand.
+type Pattern struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// PlayerStats summarises the recorded hands of a player.
+type PlayerStats struct {
+	Player       Player    `json:"player"`
+	Hands        int       `json:"hands"`
+	Wins         int       `json:"wins"`
+	TotalScore   int       `json:"total_score"`
+	AverageScore float64   `json:"average_score"`
+	Patterns     []Pattern `json:"patterns"`     // Most frequent first.
+	BiggestHand  *Hand     `json:"biggest_hand"` // The winning hand with the highest score, if any.
+	Dealer       WinRate   `json:"dealer"`       // Hands played as East.
+	NonDealer    WinRate   `json:"non_dealer"`   // Hands played in the other seats.
+}
+
+// ComputePlayerStats summarises the hands of the player that pass the filter;
+// the player of the filter is ignored. Hands played with the own wind East
+// count as played as dealer.
+func ComputePlayerStats(store Store, player ID, filter HandFilter) (*PlayerStats, error) {
+	found, err := store.Player(player)
+	if err != nil {
+		return nil, err
+	}
+	filter.Player = player
+	hands, err := store.Hands(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := PlayerStats{Player: *found, Patterns: []Pattern{}}
+	patterns := map[string]int{}
+	for idx := range hands {
+		hand := &hands[idx]
+		won := hand.Breakdown.Winning
+
+		stats.Hands++
+		stats.TotalScore += hand.Score
+		if hand.Hand.WindOwn == score.WindEast {
+			stats.Dealer.add(won)
+		} else {
+			stats.NonDealer.add(won)
+		}
+		if !won {
+			continue
+		}
+
+		stats.Wins++
+		if stats.BiggestHand == nil || hand.Score > stats.BiggestHand.Score {
+			stats.BiggestHand = hand
+		}
+		for _, detector := range hand.Breakdown.Detectors {
+			patterns[detector.Name]++
+		}
+		for _, limitHand := range hand.Breakdown.LimitHands {
+			patterns[limitHand]++
+		}
+	}
+	if stats.Hands > 0 {
+		stats.AverageScore = float64(stats.TotalScore) / float64(stats.Hands)
+	}
+
+	for name, count := range patterns {
+		stats.Patterns = append(stats.Patterns, Pattern{name, count})
+	}
+	sort.Slice(stats.Patterns, func(i, j int) bool {
+		if stats.Patterns[i].Count != stats.Patterns[j].Count {
+			return stats.Patterns[i].Count > stats.Patterns[j].Count
+		}
+		return stats.Patterns[i].Name < stats.Patterns[j].Name
+	})
+	return &stats, nil
+}
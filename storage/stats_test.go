@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+type StatsTestSuite struct{}
+
+var _ = check.Suite(&StatsTestSuite{})
+
+func (s *StatsTestSuite) TestPlayerStats(c *check.C) {
+	store := NewMemory()
+	anna := Player{Name: "Anna"}
+	assert.Nil(c, store.AddPlayer(&anna))
+	bart := Player{Name: "Bart"}
+	assert.Nil(c, store.AddPlayer(&bart))
+
+	lastSeason := time.Date(2025, 6, 1, 20, 0, 0, 0, time.UTC)
+	thisSeason := time.Date(2026, 6, 1, 20, 0, 0, 0, time.UTC)
+	hand := func(player ID, wind score.Tile, handScore int, created time.Time, detectors ...string) {
+		recorded := Hand{
+			Player:  player,
+			Hand:    score.Hand{WindOwn: wind},
+			Score:   handScore,
+			Created: created,
+			Breakdown: score.Breakdown{
+				Score:   handScore,
+				Winning: len(detectors) > 0,
+			},
+		}
+		for _, name := range detectors {
+			if name == "thirteen orphans" {
+				recorded.Breakdown.LimitHands = append(recorded.Breakdown.LimitHands, name)
+			} else {
+				recorded.Breakdown.Detectors = append(recorded.Breakdown.Detectors, score.DetectorScore{Name: name, Doubles: 1})
+			}
+		}
+		assert.Nil(c, store.AddHand(&recorded))
+	}
+
+	hand(anna.ID, score.WindEast, 100, thisSeason, "all pungs", "half-flush")
+	hand(anna.ID, score.WindSouth, 20, thisSeason)
+	hand(anna.ID, score.WindWest, 60, thisSeason, "half-flush")
+	hand(anna.ID, score.WindEast, 1000, lastSeason, "thirteen orphans")
+	hand(bart.ID, score.WindEast, 2000, thisSeason, "full flush")
+
+	stats, err := ComputePlayerStats(store, anna.ID, HandFilter{})
+	assert.Nil(c, err)
+	assert.Equal(c, "Anna", stats.Player.Name)
+	assert.Equal(c, 4, stats.Hands)
+	assert.Equal(c, 3, stats.Wins)
+	assert.InDelta(c, 295.0, stats.AverageScore, 0.001)
+	assert.Equal(c, 1000, stats.BiggestHand.Score)
+	assert.Equal(c, WinRate{2, 2, 1.0}, stats.Dealer)
+	assert.Equal(c, WinRate{2, 1, 0.5}, stats.NonDealer)
+	assert.Equal(c, []Pattern{{"half-flush", 2}, {"all pungs", 1}, {"thirteen orphans", 1}}, stats.Patterns)
+
+	// Only this season.
+	stats, err = ComputePlayerStats(store, anna.ID, HandFilter{Since: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)})
+	assert.Nil(c, err)
+	assert.Equal(c, 3, stats.Hands)
+	assert.Equal(c, 100, stats.BiggestHand.Score)
+	assert.Equal(c, WinRate{1, 1, 1.0}, stats.Dealer)
+
+	_, err = ComputePlayerStats(store, 3, HandFilter{})
+	assert.Contains(c, err.Error(), ErrNotFound.Error())
+}
+
+func (s *StatsTestSuite) TestNoHands(c *check.C) {
+	store := NewMemory()
+	anna := Player{Name: "Anna"}
+	assert.Nil(c, store.AddPlayer(&anna))
+
+	stats, err := ComputePlayerStats(store, anna.ID, HandFilter{})
+	assert.Nil(c, err)
+	assert.Equal(c, 0, stats.Hands)
+	assert.Nil(c, stats.BiggestHand)
+	assert.Empty(c, stats.Patterns)
+}
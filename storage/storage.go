@@ -0,0 +1,246 @@
+/*
+ * Storage of players, sessions, scored hands and settlements.
+ *
+ * Everything that is scored can be recorded, so that the history of a club
+ * can be looked back at. The hands themselves are stored along with their
+ * scores, so that the history can be rescored when the rules change.
+ */
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+// Standard errors.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrNoName          = errors.New("a name is required")
+	ErrDuplicatePlayer = errors.New("a player can only take part once")
+)
+
+// ID identifies a stored object. IDs are unique per kind of object, and start at 1;
+// 0 means "none".
+type ID uint64
+
+// Player is somebody who plays at the club.
+type Player struct {
+	ID      ID        `json:"id"`
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+}
+
+// Session is a series of hands played together, like a club night.
+type Session struct {
+	ID      ID        `json:"id"`
+	Name    string    `json:"name"`
+	RuleSet string    `json:"ruleset"`
+	Players []ID      `json:"players"`
+	Created time.Time `json:"created"`
+}
+
+// Hand is a hand that was scored.
+type Hand struct {
+	ID        ID              `json:"id"`
+	Session   ID              `json:"session"` // 0 when not played in a session.
+	Player    ID              `json:"player"`  // 0 when not known.
+	RuleSet   string          `json:"ruleset"`
+	Limit     *int            `json:"limit"` // Overrides the limit of the rule set when not nil.
+	Hand      score.Hand      `json:"hand"`
+	Score     int             `json:"score"`
+	Breakdown score.Breakdown `json:"breakdown"`
+	Created   time.Time       `json:"created"`
+}
+
+// Settlement records the payments after a hand.
+type Settlement struct {
+	ID         ID                    `json:"id"`
+	Session    ID                    `json:"session"` // 0 when not played in a session.
+	RuleSet    string                `json:"ruleset"`
+	Players    [score.NumPlayers]ID  `json:"players"` // 0 for players that are not known.
+	Hands      [score.NumPlayers]ID  `json:"hands"`   // The scored hands, or all 0 when only the scores are known.
+	Settlement score.Settlement      `json:"settlement"`
+	Payments   [score.NumPlayers]int `json:"payments"`
+	Created    time.Time             `json:"created"`
+}
+
+// RankingRules determine how the standings of a tournament are computed;
+// see the league package.
+type RankingRules struct {
+	Method         string                    `json:"method"`          // "raw", "placement" or "uma".
+	Placement      [score.NumPlayers]float64 `json:"placement"`       // Points for the first to fourth place.
+	Uma            [score.NumPlayers]float64 `json:"uma"`             // Bonus for the first to fourth place.
+	StartingPoints int                       `json:"starting_points"` // Points everybody starts a match with.
+	ReturnPoints   int                       `json:"return_points"`   // Points that are deducted again; the difference is the oka.
+}
+
+// Table is where four players play a match in a round of a tournament.
+type Table struct {
+	Players [score.NumPlayers]ID   `json:"players"`
+	Scores  *[score.NumPlayers]int `json:"scores"` // Points at the end of the match, or nil when not played yet.
+}
+
+// Round is one round of a tournament, in which every player plays at most one match.
+type Round struct {
+	Tables []Table `json:"tables"`
+	Byes   []ID    `json:"byes"` // Players that sit out this round.
+}
+
+// Tournament is a competition between registered players, like a league, over several rounds.
+type Tournament struct {
+	ID      ID           `json:"id"`
+	Name    string       `json:"name"`
+	RuleSet string       `json:"ruleset"`
+	Players []ID         `json:"players"`
+	Ranking RankingRules `json:"ranking"`
+	Rounds  []Round      `json:"rounds"`
+	Created time.Time    `json:"created"`
+}
+
+// HandFilter selects hands; zero values match everything.
+type HandFilter struct {
+	Session ID
+	Player  ID
+	RuleSet string
+	Since   time.Time // Hands recorded at or after this time.
+	Until   time.Time // Hands recorded before this time.
+}
+
+// Matches returns true when the hand passes the filter.
+func (f HandFilter) Matches(hand *Hand) bool {
+	return (f.Session == 0 || f.Session == hand.Session) &&
+		(f.Player == 0 || f.Player == hand.Player) &&
+		(f.RuleSet == "" || f.RuleSet == hand.RuleSet) &&
+		(f.Since.IsZero() || !hand.Created.Before(f.Since)) &&
+		(f.Until.IsZero() || hand.Created.Before(f.Until))
+}
+
+// Store keeps the history. Adding an object assigns it an ID, and sets its
+// creation time when it has none yet. Lists are ordered by ID.
+type Store interface {
+	AddPlayer(player *Player) error
+	Player(id ID) (*Player, error)
+	Players() ([]Player, error)
+
+	AddSession(session *Session) error
+	Session(id ID) (*Session, error)
+	Sessions() ([]Session, error)
+
+	AddHand(hand *Hand) error
+	UpdateHand(hand *Hand) error
+	Hand(id ID) (*Hand, error)
+	Hands(filter HandFilter) ([]Hand, error)
+
+	AddSettlement(settlement *Settlement) error
+	UpdateSettlement(settlement *Settlement) error
+	// Settlements returns those of the session, or all of them for session 0.
+	Settlements(session ID) ([]Settlement, error)
+
+	AddTournament(tournament *Tournament) error
+	UpdateTournament(tournament *Tournament) error
+	Tournament(id ID) (*Tournament, error)
+	Tournaments() ([]Tournament, error)
+
+	Close() error
+}
+
+func notFound(kind string, id ID) error {
+	return fmt.Errorf("%s %d: %s", kind, id, ErrNotFound)
+}
+
+// validateSession checks that the session has a name and that its players exist.
+func validateSession(store Store, session *Session) error {
+	if session.Name == "" {
+		return ErrNoName
+	}
+	for _, player := range session.Players {
+		if _, err := store.Player(player); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTournament checks that the tournament has a name and that its players exist.
+func validateTournament(store Store, tournament *Tournament) error {
+	if tournament.Name == "" {
+		return ErrNoName
+	}
+	if err := UniquePlayers(tournament.Players); err != nil {
+		return err
+	}
+	for _, player := range tournament.Players {
+		if _, err := store.Player(player); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UniquePlayers returns an error when a player occurs more than once.
+func UniquePlayers(players []ID) error {
+	seen := map[ID]bool{}
+	for _, player := range players {
+		if seen[player] {
+			return fmt.Errorf("player %d: %s", player, ErrDuplicatePlayer)
+		}
+		seen[player] = true
+	}
+	return nil
+}
+
+// Rescore scores all hands of the rule set again, and recomputes the payments of
+// its settlements that refer to rescored hands. This is what's needed after the
+// rule set was changed. It returns the number of hands whose score changed.
+func Rescore(store Store, rules *score.RuleSet) (int, error) {
+	hands, err := store.Hands(HandFilter{RuleSet: rules.Name})
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	scores := map[ID]int{}
+	for idx := range hands {
+		hand := &hands[idx]
+		handRules := rules
+		if hand.Limit != nil {
+			handRules = rules.WithLimit(*hand.Limit)
+		}
+		breakdown := handRules.ScoreBreakdown(&hand.Hand)
+		scores[hand.ID] = breakdown.Score
+		if breakdown.Score != hand.Score {
+			changed++
+		}
+		hand.Score, hand.Breakdown = breakdown.Score, breakdown
+		if err := store.UpdateHand(hand); err != nil {
+			return changed, err
+		}
+	}
+
+	settlements, err := store.Settlements(0)
+	if err != nil {
+		return changed, err
+	}
+	for idx := range settlements {
+		settlement := &settlements[idx]
+		if settlement.RuleSet != rules.Name || settlement.Hands[0] == 0 {
+			continue
+		}
+		for player, hand := range settlement.Hands {
+			if handScore, found := scores[hand]; found {
+				settlement.Settlement.Scores[player] = handScore
+			}
+		}
+		if settlement.Payments, err = rules.Settle(settlement.Settlement); err != nil {
+			return changed, fmt.Errorf("settlement %d: %s", settlement.ID, err)
+		}
+		if err := store.UpdateSettlement(settlement); err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+type StorageTestSuite struct{}
+
+var _ = check.Suite(&StorageTestSuite{})
+
+// stores returns every implementation, so that each test runs against all of them.
+func stores(c *check.C) map[string]Store {
+	bolt, err := OpenBolt(filepath.Join(c.MkDir(), "mahjong.db"))
+	if !assert.Nil(c, err) {
+		c.FailNow()
+	}
+	return map[string]Store{"memory": NewMemory(), "bolt": bolt}
+}
+
+func mustParseHand(c *check.C, notation string) score.Hand {
+	hand, err := score.ParseHand(notation)
+	if !assert.Nil(c, err) {
+		c.FailNow()
+	}
+	return hand
+}
+
+func (s *StorageTestSuite) TestPlayersAndSessions(c *check.C) {
+	for name, store := range stores(c) {
+		c.Log(name)
+
+		anna := Player{Name: "Anna"}
+		assert.Nil(c, store.AddPlayer(&anna))
+		bart := Player{Name: "Bart"}
+		assert.Nil(c, store.AddPlayer(&bart))
+		assert.Equal(c, ID(1), anna.ID)
+		assert.Equal(c, ID(2), bart.ID)
+		assert.False(c, anna.Created.IsZero())
+		assert.Equal(c, ErrNoName, store.AddPlayer(&Player{}))
+
+		found, err := store.Player(bart.ID)
+		assert.Nil(c, err)
+		assert.Equal(c, "Bart", found.Name)
+		_, err = store.Player(3)
+		assert.Contains(c, err.Error(), ErrNotFound.Error())
+
+		players, err := store.Players()
+		assert.Nil(c, err)
+		if assert.Len(c, players, 2) {
+			assert.Equal(c, "Anna", players[0].Name)
+		}
+
+		session := Session{Name: "Club night", RuleSet: score.DefaultRuleSetName, Players: []ID{anna.ID, bart.ID}}
+		assert.Nil(c, store.AddSession(&session))
+		assert.Equal(c, ID(1), session.ID)
+		assert.NotNil(c, store.AddSession(&Session{Name: "Ghosts", Players: []ID{42}}))
+
+		stored, err := store.Session(session.ID)
+		assert.Nil(c, err)
+		assert.Equal(c, session.Players, stored.Players)
+		sessions, err := store.Sessions()
+		assert.Nil(c, err)
+		assert.Len(c, sessions, 1)
+
+		assert.Nil(c, store.Close())
+	}
+}
+
+func (s *StorageTestSuite) TestHands(c *check.C) {
+	for name, store := range stores(c) {
+		c.Log(name)
+
+		hands := []Hand{
+			{Player: 1, Session: 1, RuleSet: "a"},
+			{Player: 2, Session: 1, RuleSet: "a"},
+			{Player: 1, RuleSet: "b", Hand: mustParseHand(c, "123b 555c EEE rr | 99s @NW")},
+		}
+		for idx := range hands {
+			assert.Nil(c, store.AddHand(&hands[idx]))
+			assert.Equal(c, ID(idx+1), hands[idx].ID)
+		}
+
+		found, err := store.Hands(HandFilter{Player: 1})
+		assert.Nil(c, err)
+		assert.Len(c, found, 2)
+		found, err = store.Hands(HandFilter{Session: 1, RuleSet: "a"})
+		assert.Nil(c, err)
+		assert.Len(c, found, 2)
+		found, err = store.Hands(HandFilter{})
+		assert.Nil(c, err)
+		assert.Len(c, found, 3)
+
+		stored, err := store.Hand(3)
+		assert.Nil(c, err)
+		assert.Equal(c, hands[2].Hand.Sets, stored.Hand.Sets)
+		assert.Equal(c, score.WindNorth, stored.Hand.WindOwn)
+
+		stored.Score = 42
+		assert.Nil(c, store.UpdateHand(stored))
+		stored, err = store.Hand(3)
+		assert.Nil(c, err)
+		assert.Equal(c, 42, stored.Score)
+		assert.NotNil(c, store.UpdateHand(&Hand{ID: 4}))
+
+		assert.Nil(c, store.Close())
+	}
+}
+
+func (s *StorageTestSuite) TestRescore(c *check.C) {
+	for name, store := range stores(c) {
+		c.Log(name)
+		rules := score.DefaultRuleSet().Copy("club")
+
+		// East wins with a self-drawn tile; the others have nothing.
+		notations := []string{"123b 555c EEE 789s rr @EE", "12b", "34c", "56s"}
+		settlement := Settlement{RuleSet: rules.Name, Settlement: score.Settlement{Winner: 0, Discarder: -1}}
+		for player, notation := range notations {
+			hand := Hand{RuleSet: rules.Name, Hand: mustParseHand(c, notation)}
+			hand.Breakdown = rules.ScoreBreakdown(&hand.Hand)
+			hand.Score = hand.Breakdown.Score
+			assert.Nil(c, store.AddHand(&hand))
+			settlement.Hands[player] = hand.ID
+			settlement.Settlement.Scores[player] = hand.Score
+		}
+		var err error
+		settlement.Payments, err = rules.Settle(settlement.Settlement)
+		assert.Nil(c, err)
+		assert.Nil(c, store.AddSettlement(&settlement))
+
+		// Nothing changes when the rules didn't change.
+		changed, err := Rescore(store, rules)
+		assert.Nil(c, err)
+		assert.Equal(c, 0, changed)
+
+		rules.WinningBonus += 10
+		changed, err = Rescore(store, rules)
+		assert.Nil(c, err)
+		assert.Equal(c, 1, changed)
+
+		winner, err := store.Hand(settlement.Hands[0])
+		assert.Nil(c, err)
+		assert.True(c, winner.Score > settlement.Settlement.Scores[0])
+		assert.Equal(c, winner.Score, winner.Breakdown.Score)
+
+		settlements, err := store.Settlements(0)
+		assert.Nil(c, err)
+		if assert.Len(c, settlements, 1) {
+			assert.Equal(c, winner.Score, settlements[0].Settlement.Scores[0])
+			assert.True(c, settlements[0].Payments[0] > settlement.Payments[0])
+		}
+
+		assert.Nil(c, store.Close())
+	}
+}
+
+func (s *StorageTestSuite) TestBoltPersists(c *check.C) {
+	filename := filepath.Join(c.MkDir(), "mahjong.db")
+	store, err := OpenBolt(filename)
+	if !assert.Nil(c, err) {
+		c.FailNow()
+	}
+	assert.Nil(c, store.AddPlayer(&Player{Name: "Anna"}))
+	assert.Nil(c, store.Close())
+
+	store, err = OpenBolt(filename)
+	if !assert.Nil(c, err) {
+		c.FailNow()
+	}
+	defer store.Close()
+	players, err := store.Players()
+	assert.Nil(c, err)
+	assert.Len(c, players, 1)
+
+	// IDs continue where they left off.
+	bart := Player{Name: "Bart"}
+	assert.Nil(c, store.AddPlayer(&bart))
+	assert.Equal(c, ID(2), bart.ID)
+}
+
+func (s *StorageTestSuite) TestTournaments(c *check.C) {
+	for name, store := range stores(c) {
+		c.Log(name)
+
+		players := []ID{}
+		for _, name := range []string{"Anna", "Bart", "Cleo", "Dirk"} {
+			player := Player{Name: name}
+			assert.Nil(c, store.AddPlayer(&player))
+			players = append(players, player.ID)
+		}
+
+		tournament := Tournament{Name: "Spring league", Players: players, Ranking: RankingRules{Method: "raw"}}
+		assert.Nil(c, store.AddTournament(&tournament))
+		assert.Equal(c, ID(1), tournament.ID)
+		assert.Equal(c, ErrNoName, store.AddTournament(&Tournament{Players: players}))
+		assert.NotNil(c, store.AddTournament(&Tournament{Name: "Ghosts", Players: []ID{42}}))
+		err := store.AddTournament(&Tournament{Name: "Twins", Players: append(players, players[0])})
+		assert.Contains(c, err.Error(), ErrDuplicatePlayer.Error())
+
+		scores := [score.NumPlayers]int{40, 10, -20, -30}
+		tournament.Rounds = []Round{{Tables: []Table{{Players: [score.NumPlayers]ID{4, 3, 2, 1}, Scores: &scores}}}}
+		assert.Nil(c, store.UpdateTournament(&tournament))
+
+		// Changing the scores doesn't change the stored tournament, until it's updated.
+		scores[0] = 1000
+		stored, err := store.Tournament(tournament.ID)
+		assert.Nil(c, err)
+		if assert.Len(c, stored.Rounds, 1) {
+			assert.Equal(c, [score.NumPlayers]ID{4, 3, 2, 1}, stored.Rounds[0].Tables[0].Players)
+			assert.Equal(c, 40, stored.Rounds[0].Tables[0].Scores[0])
+		}
+
+		tournaments, err := store.Tournaments()
+		assert.Nil(c, err)
+		assert.Len(c, tournaments, 1)
+		_, err = store.Tournament(2)
+		assert.Contains(c, err.Error(), ErrNotFound.Error())
+
+		assert.Nil(c, store.Close())
+	}
+}
@@ -0,0 +1,101 @@
+package table
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/wall"
+)
+
+// ErrUnknownTable is returned when looking up a table that doesn't exist (anymore).
+var ErrUnknownTable = errors.New("no such table")
+
+// Lobby keeps track of the open tables.
+type Lobby struct {
+	timeout     time.Duration
+	idleTimeout time.Duration
+
+	mutex  sync.Mutex
+	tables map[string]*Table
+	lastID int
+}
+
+// NewLobby returns a lobby whose tables give players the timeout for each action.
+// Tables that no human player joined within the idle timeout are closed.
+func NewLobby(timeout, idleTimeout time.Duration) *Lobby {
+	return &Lobby{
+		timeout:     timeout,
+		idleTimeout: idleTimeout,
+		tables:      map[string]*Table{},
+	}
+}
+
+// Open opens a new table, which plays with the given rules. The table is
+// forgotten once it closes, which it does by itself when nobody joins it.
+func (l *Lobby) Open(rules *score.RuleSet) *Table {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.lastID++
+	t := newTable(strconv.Itoa(l.lastID), rules, l.timeout, l.idleTimeout, wall.RandomSeed())
+	l.tables[t.ID] = t
+
+	go func() {
+		<-t.Done()
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		delete(l.tables, t.ID)
+	}()
+	return t
+}
+
+// Lookup returns the open table with the given ID.
+func (l *Lobby) Lookup(id string) (*Table, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	t, found := l.tables[id]
+	if !found {
+		return nil, ErrUnknownTable
+	}
+	return t, nil
+}
+
+// Tables describes all open tables, oldest first.
+func (l *Lobby) Tables() []Info {
+	l.mutex.Lock()
+	tables := make([]*Table, 0, len(l.tables))
+	for _, t := range l.tables {
+		tables = append(tables, t)
+	}
+	l.mutex.Unlock()
+
+	infos := make([]Info, 0, len(tables))
+	for _, t := range tables {
+		infos = append(infos, t.Info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		first, _ := strconv.Atoi(infos[i].ID)
+		second, _ := strconv.Atoi(infos[j].ID)
+		return first < second
+	})
+	return infos
+}
+
+// Shutdown closes all tables, and waits until they are closed.
+func (l *Lobby) Shutdown() {
+	l.mutex.Lock()
+	tables := make([]*Table, 0, len(l.tables))
+	for _, t := range l.tables {
+		tables = append(tables, t)
+	}
+	l.mutex.Unlock()
+
+	for _, t := range tables {
+		t.Close()
+	}
+}
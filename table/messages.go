@@ -0,0 +1,70 @@
+package table
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sybrenstuvel/mahjong/game"
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+// MessageType says what a message to a player is about.
+type MessageType int
+
+// The messages a table sends to its players.
+const (
+	MessageSeats         MessageType = iota // Who sits where; sent whenever somebody joins or leaves.
+	MessageHand                             // A new hand is dealt.
+	MessageEvent                            // Something happened in the hand.
+	MessageActions                          // What the player can do now, and until when.
+	MessageError                            // The last action of the player was refused.
+	MessageHandFinished                     // The hand is over, and was added to the history of the match.
+	MessageMatchFinished                    // The match is over; the table closes.
+)
+
+var messageTypeNames = map[MessageType]string{
+	MessageSeats:         "seats",
+	MessageHand:          "hand",
+	MessageEvent:         "event",
+	MessageActions:       "actions",
+	MessageError:         "error",
+	MessageHandFinished:  "hand_finished",
+	MessageMatchFinished: "match_finished",
+}
+
+func (messageType MessageType) String() string {
+	name, found := messageTypeNames[messageType]
+	if !found {
+		return fmt.Sprintf("MessageType(%d)", int(messageType))
+	}
+	return name
+}
+
+// MarshalJSON converts a message type to its name in JSON.
+func (messageType MessageType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(messageType.String())
+}
+
+// HandInfo describes the hand that is dealt.
+type HandInfo struct {
+	Number    int        `json:"number"`
+	Dealer    int        `json:"dealer"`
+	RoundWind score.Tile `json:"round_wind"`
+	SeatWind  score.Tile `json:"seat_wind"` // The wind of the receiving player.
+}
+
+// Message is sent from the table to a player. Only the fields that belong to
+// the type of message are set.
+type Message struct {
+	Type     MessageType               `json:"type"`
+	Seat     int                       `json:"seat"` // The seat of the receiving player.
+	Players  *[score.NumPlayers]string `json:"players,omitempty"`
+	Hand     *HandInfo                 `json:"hand,omitempty"`
+	Event    *game.Event               `json:"event,omitempty"`
+	Actions  []game.Action             `json:"actions,omitempty"`
+	Deadline *time.Time                `json:"deadline,omitempty"`
+	Record   *game.HandRecord          `json:"record,omitempty"`
+	Scores   *[score.NumPlayers]int    `json:"scores,omitempty"`
+	Error    string                    `json:"error,omitempty"`
+}
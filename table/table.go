@@ -0,0 +1,569 @@
+/*
+ * A table where four players play a match, each through their own connection.
+ *
+ * Every table runs in its own goroutine, which is the only one touching the
+ * match; players talk to it through channels. Each player only receives their
+ * own private tiles, plus what everybody at the table can see. When a player
+ * doesn't act before the deadline, the default action is taken for them, and
+ * seats that were left are played that way without waiting at all. Seats can
+ * also be given to bots. The table closes when the match is over, when the
+ * last human player leaves, or when no human player joined it for a while.
+ */
+
+package table
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sybrenstuvel/mahjong/game"
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+// MessageBuffer is the number of messages that can wait for a player. A player
+// that falls further behind is removed from the table.
+const MessageBuffer = 512
+
+// DefaultIdleTimeout is how long a table stays open without any human player joining it.
+const DefaultIdleTimeout = 10 * time.Minute
+
+// Standard errors.
+var (
+	ErrTableFull   = errors.New("table is full")
+	ErrTableClosed = errors.New("table is closed")
+	ErrNoName      = errors.New("players need a name")
+)
+
+// Info describes a table, for showing in a list of tables.
+type Info struct {
+	ID      string                   `json:"id"`
+	RuleSet string                   `json:"ruleset"`
+	Players [score.NumPlayers]string `json:"players"` // Empty for free seats.
+	Playing bool                     `json:"playing"`
+	Hand    int                      `json:"hand"` // The number of the hand being played.
+}
+
+// Player is a seat at the table, as used by the connection of the player.
+// Seats played by a bot have a Player without messages.
+type Player struct {
+	Seat int
+	Name string
+
+	table    *Table
+	messages chan Message
+	leave    sync.Once
+	bot      game.Bot
+}
+
+// Messages returns what the table sends to the player. It is closed when the
+// player leaves, or when the table closes.
+func (p *Player) Messages() <-chan Message {
+	return p.messages
+}
+
+// Act sends the action to the table. It is always taken on behalf of the player's
+// own seat; refused actions are answered with an error message.
+func (p *Player) Act(action game.Action) {
+	action.Seat = p.Seat
+	select {
+	case p.table.actions <- playerAction{p, action}:
+	case <-p.table.done:
+	}
+}
+
+// Leave gives up the seat. The table plays it until somebody else takes it.
+func (p *Player) Leave() {
+	p.leave.Do(func() {
+		select {
+		case p.table.leaves <- p:
+		case <-p.table.done:
+		}
+	})
+}
+
+type joinRequest struct {
+	name  string
+	bot   game.Bot // nil for human players.
+	reply chan joinReply
+}
+
+type joinReply struct {
+	player *Player
+	err    error
+}
+
+type playerAction struct {
+	player *Player
+	action game.Action
+}
+
+// Table runs a match between the players that joined it.
+type Table struct {
+	ID          string
+	rules       *score.RuleSet
+	timeout     time.Duration
+	idleTimeout time.Duration
+	seed        int64
+
+	joins   chan joinRequest
+	leaves  chan *Player
+	actions chan playerAction
+	stop    chan struct{}
+	done    chan struct{}
+	closing sync.Once
+
+	infoMutex sync.RWMutex
+	info      Info
+
+	// Only used by the goroutine of the table.
+	joined     bool // Whether a human player ever joined.
+	players    [score.NumPlayers]*Player
+	match      *game.Match
+	eventsSent int
+	deadline   time.Time
+	timer      *time.Timer
+	logger     *log.Entry
+}
+
+// New opens a table, which starts the match once four players joined. Players
+// get the timeout for each action. The seed determines the walls of the match.
+// The table closes after DefaultIdleTimeout when no human player joins it.
+func New(id string, rules *score.RuleSet, timeout time.Duration, seed int64) *Table {
+	return newTable(id, rules, timeout, DefaultIdleTimeout, seed)
+}
+
+func newTable(id string, rules *score.RuleSet, timeout, idleTimeout time.Duration, seed int64) *Table {
+	t := &Table{
+		ID:          id,
+		rules:       rules,
+		timeout:     timeout,
+		idleTimeout: idleTimeout,
+		seed:        seed,
+		joins:       make(chan joinRequest),
+		leaves:      make(chan *Player),
+		actions:     make(chan playerAction),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+		info:        Info{ID: id, RuleSet: rules.Name},
+		timer:       time.NewTimer(timeout),
+		logger:      log.WithField("table", id),
+	}
+	t.timer.Stop()
+
+	go t.run()
+	return t
+}
+
+// Join seats the player at the first free seat.
+func (t *Table) Join(name string) (*Player, error) {
+	if name == "" {
+		return nil, ErrNoName
+	}
+
+	return t.seat(joinRequest{name: name})
+}
+
+// AddBot lets the bot play the first free seat, for the rest of the match.
+// Returns the seat of the bot.
+func (t *Table) AddBot(name string, bot game.Bot) (int, error) {
+	if name == "" {
+		return -1, ErrNoName
+	}
+	player, err := t.seat(joinRequest{name: name, bot: bot})
+	if err != nil {
+		return -1, err
+	}
+	return player.Seat, nil
+}
+
+func (t *Table) seat(request joinRequest) (*Player, error) {
+	request.reply = make(chan joinReply, 1)
+	select {
+	case t.joins <- request:
+	case <-t.done:
+		return nil, ErrTableClosed
+	}
+	reply := <-request.reply
+	return reply.player, reply.err
+}
+
+// Info returns a description of the table.
+func (t *Table) Info() Info {
+	t.infoMutex.RLock()
+	defer t.infoMutex.RUnlock()
+	return t.info
+}
+
+// Done returns a channel that is closed once the table is closed.
+func (t *Table) Done() <-chan struct{} {
+	return t.done
+}
+
+// Close stops the match, disconnects all players, and waits for the table to close.
+func (t *Table) Close() {
+	t.closing.Do(func() { close(t.stop) })
+	<-t.done
+}
+
+func (t *Table) run() {
+	defer t.shutdown()
+	t.logger.Info("table opened")
+	idle := time.NewTimer(t.idleTimeout)
+	defer idle.Stop()
+
+	for {
+		select {
+		case request := <-t.joins:
+			player, err := t.join(request)
+			// Reply once the info is up to date, so that it includes the new player.
+			t.updateInfo()
+			request.reply <- joinReply{player, err}
+		case player := <-t.leaves:
+			t.leave(player)
+		case pa := <-t.actions:
+			t.act(pa.player, pa.action)
+		case <-t.timer.C:
+			t.expire()
+		case <-idle.C:
+			if !t.joined {
+				t.logger.Info("nobody joined, closing table")
+				return
+			}
+		case <-t.stop:
+			return
+		}
+
+		t.updateInfo()
+		if (t.joined && t.isEmpty()) || (t.match != nil && t.match.Finished) {
+			return
+		}
+	}
+}
+
+func (t *Table) shutdown() {
+	t.timer.Stop()
+	for seat, player := range t.players {
+		if player != nil && player.bot == nil {
+			close(player.messages)
+		}
+		t.players[seat] = nil
+	}
+	t.updateInfo()
+	close(t.done)
+	t.logger.Info("table closed")
+}
+
+// isEmpty returns true when no human players are left.
+func (t *Table) isEmpty() bool {
+	for _, player := range t.players {
+		if player != nil && player.bot == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Table) updateInfo() {
+	info := Info{ID: t.ID, RuleSet: t.rules.Name}
+	for seat, player := range t.players {
+		if player != nil {
+			info.Players[seat] = player.Name
+		}
+	}
+	if t.match != nil && !t.match.Finished {
+		info.Playing = true
+		info.Hand = len(t.match.History) + 1
+	}
+
+	t.infoMutex.Lock()
+	defer t.infoMutex.Unlock()
+	t.info = info
+}
+
+// send queues the message for the player, and removes players that can't keep up.
+func (t *Table) send(player *Player, message Message) {
+	if player == nil || player.bot != nil || t.players[player.Seat] != player {
+		return
+	}
+	message.Seat = player.Seat
+	select {
+	case player.messages <- message:
+	default:
+		t.logger.WithField("player", player.Name).Warning("player is not keeping up, removing from table")
+		t.players[player.Seat] = nil
+		close(player.messages)
+	}
+}
+
+func (t *Table) broadcast(message Message) {
+	for _, player := range t.players {
+		t.send(player, message)
+	}
+}
+
+func (t *Table) sendSeats() {
+	names := [score.NumPlayers]string{}
+	for seat, player := range t.players {
+		if player != nil {
+			names[seat] = player.Name
+		}
+	}
+	t.broadcast(Message{Type: MessageSeats, Players: &names})
+}
+
+func (t *Table) join(request joinRequest) (*Player, error) {
+	seat := -1
+	for idx, player := range t.players {
+		if player == nil {
+			seat = idx
+			break
+		}
+	}
+	if seat < 0 {
+		return nil, ErrTableFull
+	}
+
+	player := &Player{
+		Seat:  seat,
+		Name:  request.name,
+		table: t,
+		bot:   request.bot,
+	}
+	if player.bot == nil {
+		player.messages = make(chan Message, MessageBuffer)
+		t.joined = true
+	}
+	t.players[seat] = player
+	t.logger.WithFields(log.Fields{"player": player.Name, "seat": seat, "bot": player.bot != nil}).Info("player joined")
+	t.sendSeats()
+
+	switch {
+	case t.match == nil:
+		if t.isFull() {
+			t.startMatch()
+		}
+	case player.bot != nil:
+		// The bot takes over a seat that was left during the match.
+		t.match.SetBot(seat, player.bot)
+		t.match.Game().SetBot(seat, player.bot)
+		t.progress()
+	default:
+		// Catch up with the hand that is being played.
+		g := t.match.Game()
+		t.send(player, t.handMessage(seat))
+		for _, event := range g.Events(0)[:t.eventsSent] {
+			visible := event.For(seat)
+			t.send(player, Message{Type: MessageEvent, Event: &visible})
+		}
+		t.sendActions(player)
+	}
+	return player, nil
+}
+
+func (t *Table) isFull() bool {
+	for _, player := range t.players {
+		if player == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Table) leave(player *Player) {
+	if t.players[player.Seat] != player {
+		return
+	}
+	t.players[player.Seat] = nil
+	close(player.messages)
+	t.logger.WithFields(log.Fields{"player": player.Name, "seat": player.Seat}).Info("player left")
+
+	t.sendSeats()
+	if t.match != nil && !t.isEmpty() {
+		t.progress()
+	}
+}
+
+func (t *Table) startMatch() {
+	names := [score.NumPlayers]string{}
+	for seat, player := range t.players {
+		names[seat] = player.Name
+	}
+	t.match = game.NewMatch(t.rules, names, t.seed)
+	for seat, player := range t.players {
+		if player.bot != nil {
+			t.match.SetBot(seat, player.bot)
+		}
+	}
+	t.logger.WithField("seed", t.seed).Info("starting match")
+	t.startHand()
+	t.progress()
+}
+
+func (t *Table) startHand() {
+	if _, err := t.match.StartHand(); err != nil {
+		// Only happens when the table is confused about the state of the match.
+		t.logger.WithError(err).Error("unable to start hand")
+		return
+	}
+	t.eventsSent = 0
+	for seat, player := range t.players {
+		t.send(player, t.handMessage(seat))
+	}
+}
+
+func (t *Table) handMessage(seat int) Message {
+	g := t.match.Game()
+	return Message{Type: MessageHand, Hand: &HandInfo{
+		Number:    len(t.match.History) + 1,
+		Dealer:    g.Dealer(),
+		RoundWind: g.RoundWind(),
+		SeatWind:  game.SeatWind(seat, g.Dealer()),
+	}}
+}
+
+func (t *Table) act(player *Player, action game.Action) {
+	if t.players[player.Seat] != player {
+		return
+	}
+	if t.match == nil || t.match.Game() == nil {
+		t.send(player, Message{Type: MessageError, Error: "the match has not started yet"})
+		return
+	}
+
+	if err := t.match.Game().Apply(action); err != nil {
+		t.send(player, Message{Type: MessageError, Error: err.Error()})
+		t.sendActions(player)
+		return
+	}
+	// Without anything happening, only the player needs to know they're done.
+	if !t.progress() && t.match.Game() != nil {
+		t.sendActions(player)
+	}
+}
+
+// expire takes the default action for everybody who didn't act before the deadline.
+// Only the actions the players were waiting on are taken; a discard that can be
+// claimed gives the other players their own deadline.
+func (t *Table) expire() {
+	g := t.match.Game()
+	expired := []game.Action{}
+	for seat := range t.players {
+		if action, ok := g.DefaultAction(seat); ok {
+			expired = append(expired, action)
+		}
+	}
+	for _, action := range expired {
+		t.logger.WithField("action", action).Debug("turn timed out")
+		if err := g.Apply(action); err != nil {
+			t.logger.WithError(err).Warning("unable to take the default action")
+		}
+	}
+	if !t.progress() {
+		t.restartTimer()
+	}
+}
+
+// progress plays the empty seats, sends out what happened, and moves on to the
+// next hand when the current one is over. Returns whether anything happened.
+func (t *Table) progress() bool {
+	happened := false
+	for {
+		g := t.match.Game()
+		played := false
+		for seat, player := range t.players {
+			if player != nil {
+				continue
+			}
+			if action, ok := g.DefaultAction(seat); ok {
+				g.Apply(action)
+				played = true
+			}
+		}
+
+		if t.sendEvents(g) {
+			happened = true
+		}
+		if g.Finished() {
+			t.finishHand()
+			if t.match.Finished {
+				return true
+			}
+			t.startHand()
+			continue
+		}
+		if !played {
+			break
+		}
+	}
+
+	if happened {
+		t.restartTimer()
+	}
+	return happened
+}
+
+// sendEvents sends the events nobody saw yet, and returns whether there were any.
+func (t *Table) sendEvents(g *game.Game) bool {
+	events := g.Events(t.eventsSent)
+	if len(events) == 0 {
+		return false
+	}
+	t.eventsSent += len(events)
+
+	for _, player := range t.players {
+		if player == nil {
+			continue
+		}
+		for _, event := range events {
+			visible := event.For(player.Seat)
+			t.send(player, Message{Type: MessageEvent, Event: &visible})
+		}
+	}
+	return true
+}
+
+// restartTimer gives everybody who can act a new deadline, and tells them what they can do.
+func (t *Table) restartTimer() {
+	t.timer.Stop()
+	select {
+	case <-t.timer.C:
+	default:
+	}
+
+	t.deadline = time.Now().Add(t.timeout)
+	t.timer.Reset(t.timeout)
+	for _, player := range t.players {
+		t.sendActions(player)
+	}
+}
+
+func (t *Table) sendActions(player *Player) {
+	if player == nil {
+		return
+	}
+	deadline := t.deadline
+	t.send(player, Message{
+		Type:     MessageActions,
+		Actions:  t.match.Game().ValidActions(player.Seat),
+		Deadline: &deadline,
+	})
+}
+
+func (t *Table) finishHand() {
+	record, err := t.match.FinishHand()
+	if err != nil {
+		t.logger.WithError(err).Error("unable to finish hand")
+		return
+	}
+	t.timer.Stop()
+
+	scores := t.match.Scores
+	t.broadcast(Message{Type: MessageHandFinished, Record: record, Scores: &scores})
+	if t.match.Finished {
+		t.logger.WithField("scores", scores).Info("match finished")
+		t.broadcast(Message{Type: MessageMatchFinished, Scores: &scores})
+	}
+}
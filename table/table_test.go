@@ -0,0 +1,333 @@
+package table
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	check "gopkg.in/check.v1"
+
+	"github.com/sybrenstuvel/mahjong/game"
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+type TableTestSuite struct{}
+
+var _ = check.Suite(&TableTestSuite{})
+
+// receive returns the next message of the given type, skipping the others.
+func receive(c *check.C, player *Player, messageType MessageType) Message {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case message, ok := <-player.Messages():
+			if !ok {
+				c.Fatalf("%s: messages closed while waiting for %s", player.Name, messageType)
+			}
+			if message.Type == messageType {
+				return message
+			}
+		case <-timeout:
+			c.Fatalf("%s: timeout waiting for %s", player.Name, messageType)
+		}
+	}
+}
+
+func joinAll(c *check.C, t *Table) [score.NumPlayers]*Player {
+	players := [score.NumPlayers]*Player{}
+	for seat, name := range []string{"Anna", "Bart", "Cleo", "Dirk"} {
+		player, err := t.Join(name)
+		if !assert.Nil(c, err) {
+			c.FailNow()
+		}
+		assert.Equal(c, seat, player.Seat)
+		players[seat] = player
+	}
+	return players
+}
+
+func (s *TableTestSuite) TestPrivateTiles(c *check.C) {
+	t := New("1", score.DefaultRuleSet(), time.Minute, 1)
+	defer t.Close()
+	players := joinAll(c, t)
+
+	_, err := t.Join("Eve")
+	assert.Equal(c, ErrTableFull, err)
+	assert.True(c, t.Info().Playing)
+
+	for seat, player := range players {
+		hand := receive(c, player, MessageHand)
+		assert.Equal(c, 0, hand.Hand.Dealer)
+		assert.Equal(c, score.WindEast+score.Tile(seat), hand.Hand.SeatWind)
+
+		// Everybody sees all deals, but only the tiles of their own.
+		for deal := 0; deal < score.NumPlayers; deal++ {
+			message := receive(c, player, MessageEvent)
+			assert.Equal(c, game.EventDeal, message.Event.Type)
+			if message.Event.Seat == seat {
+				assert.NotEmpty(c, message.Event.Tiles)
+			} else {
+				assert.Empty(c, message.Event.Tiles)
+			}
+		}
+	}
+}
+
+func (s *TableTestSuite) TestActions(c *check.C) {
+	t := New("1", score.DefaultRuleSet(), time.Minute, 1)
+	defer t.Close()
+	players := joinAll(c, t)
+
+	actions := receive(c, players[0], MessageActions)
+	assert.NotEmpty(c, actions.Actions)
+	assert.True(c, actions.Deadline.After(time.Now()))
+
+	// Acting out of turn is refused.
+	players[1].Act(game.Action{Type: game.ActionDiscard, Tile: score.WindEast})
+	message := receive(c, players[1], MessageError)
+	assert.Contains(c, message.Error, game.ErrInvalidAction.Error())
+
+	var discard game.Action
+	for _, action := range actions.Actions {
+		if action.Type == game.ActionDiscard {
+			discard = action
+		}
+	}
+	// The seat is always that of the player.
+	discard.Seat = 3
+	players[0].Act(discard)
+
+	for _, player := range players {
+		var event *game.Event
+		for event == nil || event.Type != game.EventDiscard {
+			event = receive(c, player, MessageEvent).Event
+		}
+		assert.Equal(c, 0, event.Seat)
+		assert.Equal(c, []score.Tile{discard.Tile}, event.Tiles)
+	}
+}
+
+func (s *TableTestSuite) TestTimeout(c *check.C) {
+	t := New("1", score.DefaultRuleSet(), 20*time.Millisecond, 1)
+	defer t.Close()
+	players := joinAll(c, t)
+
+	// Without doing anything, the dealer discards after the timeout.
+	for {
+		event := receive(c, players[2], MessageEvent).Event
+		if event.Type == game.EventDiscard {
+			assert.Equal(c, 0, event.Seat)
+			break
+		}
+	}
+}
+
+// hasAction returns whether one of the actions is of the type.
+func hasAction(actions []game.Action, actionType game.ActionType) bool {
+	for _, action := range actions {
+		if action.Type == actionType {
+			return true
+		}
+	}
+	return false
+}
+
+// pungableSeed returns a seed for which the dealer's default discard can be
+// punged by another seat, along with that seat.
+func pungableSeed(c *check.C) (int64, int) {
+	for seed := int64(1); seed < 1000; seed++ {
+		match := game.NewMatch(score.DefaultRuleSet(), [score.NumPlayers]string{}, seed)
+		g, err := match.StartHand()
+		if !assert.Nil(c, err) {
+			c.FailNow()
+		}
+		discard, _ := g.DefaultAction(g.Dealer())
+		if g.Apply(discard) != nil || g.Phase() != game.PhaseClaim {
+			continue
+		}
+		for seat := 0; seat < score.NumPlayers; seat++ {
+			if hasAction(g.ValidActions(seat), game.ActionPung) {
+				return seed, seat
+			}
+		}
+	}
+	c.Fatal("no seed with a pungable first discard")
+	return 0, 0
+}
+
+func (s *TableTestSuite) TestTimeoutOpensClaims(c *check.C) {
+	seed, claimer := pungableSeed(c)
+	t := New("1", score.DefaultRuleSet(), 200*time.Millisecond, seed)
+	defer t.Close()
+	players := joinAll(c, t)
+
+	// The dealer's discard times out, but the claimer still gets to respond to it.
+	message := receive(c, players[claimer], MessageActions)
+	for len(message.Actions) == 0 {
+		message = receive(c, players[claimer], MessageActions)
+	}
+	assert.True(c, hasAction(message.Actions, game.ActionPung), "actions: %v", message.Actions)
+	assert.True(c, message.Deadline.After(time.Now()))
+}
+
+func (s *TableTestSuite) TestEmptySeatsArePlayed(c *check.C) {
+	// Hands without winner would keep the deal with the dealer forever.
+	rules := score.DefaultRuleSet().Copy("rotating")
+	rules.Dealer = score.DealerRules{}
+	t := New("1", rules, time.Minute, 1)
+	defer t.Close()
+	players := joinAll(c, t)
+	for _, player := range players[1:] {
+		player.Leave()
+	}
+
+	// Play the whole match by discarding and passing; the others don't wait for a timeout.
+	anna := players[0]
+	hands := 0
+	for message := range anna.Messages() {
+		switch message.Type {
+		case MessageActions:
+			if len(message.Actions) == 0 {
+				continue
+			}
+			action := message.Actions[0]
+			for _, option := range message.Actions {
+				if option.Type == game.ActionDiscard {
+					action = option
+				}
+			}
+			anna.Act(action)
+		case MessageHandFinished:
+			hands++
+		case MessageMatchFinished:
+			assert.NotNil(c, message.Scores)
+		}
+	}
+
+	assert.Equal(c, 16, hands)
+	<-t.Done()
+	assert.False(c, t.Info().Playing)
+}
+
+func (s *TableTestSuite) TestCloseWhenEmpty(c *check.C) {
+	t := New("1", score.DefaultRuleSet(), time.Minute, 1)
+	player, err := t.Join("Anna")
+	assert.Nil(c, err)
+	assert.Equal(c, "Anna", t.Info().Players[0])
+	assert.False(c, t.Info().Playing)
+
+	player.Leave()
+	select {
+	case <-t.Done():
+	case <-time.After(5 * time.Second):
+		c.Fatal("table didn't close")
+	}
+
+	_, err = t.Join("Bart")
+	assert.Equal(c, ErrTableClosed, err)
+	for range player.Messages() {
+		// The channel is closed after the messages sent before leaving.
+	}
+}
+
+func (s *TableTestSuite) TestCloseWhenIdle(c *check.C) {
+	// Bots don't keep the table open.
+	t := newTable("1", score.DefaultRuleSet(), time.Minute, 50*time.Millisecond, 1)
+	_, err := t.AddBot("Bot", discardingBot{})
+	assert.Nil(c, err)
+	select {
+	case <-t.Done():
+	case <-time.After(5 * time.Second):
+		c.Fatal("idle table didn't close")
+	}
+
+	// Human players do, until they leave.
+	t = newTable("2", score.DefaultRuleSet(), time.Minute, 50*time.Millisecond, 1)
+	player, err := t.Join("Anna")
+	assert.Nil(c, err)
+	select {
+	case <-t.Done():
+		c.Fatal("table closed while a player was seated")
+	case <-time.After(200 * time.Millisecond):
+	}
+	player.Leave()
+	<-t.Done()
+
+	// The lobby forgets idle tables.
+	lobby := NewLobby(time.Minute, 50*time.Millisecond)
+	lobby.Open(score.DefaultRuleSet())
+	deadline := time.After(5 * time.Second)
+	for len(lobby.Tables()) > 0 {
+		select {
+		case <-deadline:
+			c.Fatal("lobby kept the idle table")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func (s *TableTestSuite) TestLobby(c *check.C) {
+	lobby := NewLobby(time.Minute, time.Minute)
+	first := lobby.Open(score.DefaultRuleSet())
+	second := lobby.Open(score.DefaultRuleSet())
+
+	found, err := lobby.Lookup(second.ID)
+	assert.Nil(c, err)
+	assert.Equal(c, second, found)
+	_, err = lobby.Lookup("nonexistent")
+	assert.Equal(c, ErrUnknownTable, err)
+
+	infos := lobby.Tables()
+	if assert.Len(c, infos, 2) {
+		assert.Equal(c, first.ID, infos[0].ID)
+		assert.Equal(c, score.DefaultRuleSetName, infos[0].RuleSet)
+	}
+
+	lobby.Shutdown()
+	for len(lobby.Tables()) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	_, err = lobby.Lookup(first.ID)
+	assert.Equal(c, ErrUnknownTable, err)
+}
+
+// discardingBot never claims, and lets the game pick its discard.
+type discardingBot struct{}
+
+func (discardingBot) Turn(view *game.View, actions []game.Action) game.Action {
+	return game.Action{}
+}
+
+func (discardingBot) Claim(view *game.View, actions []game.Action) game.Action {
+	return game.Action{Seat: view.Seat, Type: game.ActionPass}
+}
+
+func (s *TableTestSuite) TestBots(c *check.C) {
+	t := New("1", score.DefaultRuleSet(), time.Minute, 1)
+	defer t.Close()
+
+	for expected := 0; expected < 3; expected++ {
+		seat, err := t.AddBot("Bot", discardingBot{})
+		assert.Nil(c, err)
+		assert.Equal(c, expected, seat)
+	}
+	// A table with only bots stays open for players to join.
+	assert.False(c, t.Info().Playing)
+
+	anna, err := t.Join("Anna")
+	assert.Nil(c, err)
+	assert.Equal(c, 3, anna.Seat)
+	assert.True(c, t.Info().Playing)
+
+	// The bots play until Anna can claim a discard, or it is her turn.
+	discards := 0
+	for message := range anna.Messages() {
+		if message.Type == MessageEvent && message.Event.Type == game.EventDiscard {
+			assert.NotEqual(c, anna.Seat, message.Event.Seat)
+			discards++
+		}
+		if message.Type == MessageActions && len(message.Actions) > 0 {
+			break
+		}
+	}
+	assert.True(c, discards > 0)
+}
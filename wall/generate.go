@@ -0,0 +1,264 @@
+/*
+ * Generation of random hands, for scoring practice.
+ *
+ * Hands are built from the tiles of a shuffled wall, so that they never
+ * contain more tiles than the game has, and are reproducible from the seed.
+ */
+
+package wall
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+// HandKind is the kind of hand to generate.
+type HandKind int
+
+// The kinds of hands that can be generated.
+const (
+	WinningHand HandKind = iota // Four sets and a pillow.
+	ReadyHand                   // One tile short of a winning hand.
+)
+
+// Standard errors.
+var (
+	ErrUnknownHandKind = errors.New("unknown kind of hand")
+	ErrNoHandGenerated = errors.New("unable to generate a hand")
+)
+
+var handKindNames = map[string]HandKind{
+	"winning": WinningHand,
+	"ready":   ReadyHand,
+}
+
+// ParseHandKind returns the kind of hand with the given name, "winning" or "ready".
+func ParseHandKind(name string) (HandKind, error) {
+	kind, found := handKindNames[name]
+	if !found {
+		return WinningHand, ErrUnknownHandKind
+	}
+	return kind, nil
+}
+
+// GenerateOptions determine what kind of hand is generated.
+type GenerateOptions struct {
+	Kind    HandKind
+	Flush   bool // Only use tiles of a single suit.
+	Flowers bool // Add at least one flower or season.
+}
+
+// maxAttempts is how often generation is retried when the wall runs out of suitable tiles.
+const maxAttempts = 100
+
+// generator builds a hand from the tiles of a wall.
+type generator struct {
+	rng    *rand.Rand
+	order  []score.Tile // The tiles in the order they come from the wall.
+	counts map[score.Tile]int
+	suit   score.Tile // Only tiles of this suit may be used, unless it is NoTile.
+}
+
+func newGenerator(rng *rand.Rand, w *Wall) *generator {
+	g := generator{
+		rng:    rng,
+		counts: map[score.Tile]int{},
+	}
+	for !w.IsEmpty() {
+		tile, _ := w.Draw()
+		g.order = append(g.order, tile)
+	}
+	for w.DeadRemaining() > 0 {
+		tile, _ := w.DrawReplacement()
+		g.order = append(g.order, tile)
+	}
+	for _, tile := range g.order {
+		g.counts[tile]++
+	}
+	return &g
+}
+
+func (g *generator) suitable(tile score.Tile) bool {
+	if tile.IsBonus() || g.counts[tile] == 0 {
+		return false
+	}
+	return g.suit == score.NoTile || tile.Suit() == g.suit
+}
+
+func (g *generator) take(tiles ...score.Tile) []score.Tile {
+	for _, tile := range tiles {
+		g.counts[tile]--
+	}
+	return tiles
+}
+
+// chowStarts returns the first tiles of the chows that the tile can be part of.
+func (g *generator) chowStarts(tile score.Tile) []score.Tile {
+	starts := []score.Tile{}
+	number := tile.Number()
+	for start := tile - 2; start <= tile; start++ {
+		first := number - int(tile-start)
+		if first < 1 || first > 7 {
+			continue
+		}
+		if g.suitable(start) && g.suitable(start+1) && g.suitable(start+2) {
+			starts = append(starts, start)
+		}
+	}
+	return starts
+}
+
+// nextSet takes the next suitable tile from the wall, and builds a set around it.
+func (g *generator) nextSet() (score.Set, bool) {
+	for _, tile := range g.order {
+		if !g.suitable(tile) {
+			continue
+		}
+
+		starts := g.chowStarts(tile)
+		count := g.counts[tile]
+		switch roll := g.rng.Intn(10); {
+		case roll < 1 && count >= 4:
+			return score.Set{Tiles: g.take(tile, tile, tile, tile)}, true
+		case roll < 5 && count >= 3:
+			return score.Set{Tiles: g.take(tile, tile, tile)}, true
+		case len(starts) > 0:
+			start := starts[g.rng.Intn(len(starts))]
+			return score.Set{Tiles: g.take(start, start+1, start+2)}, true
+		case count >= 3:
+			return score.Set{Tiles: g.take(tile, tile, tile)}, true
+		}
+	}
+	return score.Set{}, false
+}
+
+// nextPillow takes the next suitable tile from the wall that can form a pillow.
+func (g *generator) nextPillow() (score.Set, bool) {
+	for _, tile := range g.order {
+		if g.suitable(tile) && g.counts[tile] >= 2 {
+			return score.Set{Tiles: g.take(tile, tile), Concealed: true}, true
+		}
+	}
+	return score.Set{}, false
+}
+
+// bonusTiles takes the first flowers and seasons from the wall.
+func (g *generator) bonusTiles(amount int) []score.Tile {
+	bonus := []score.Tile{}
+	for _, tile := range g.order {
+		if len(bonus) < amount && tile.IsBonus() && g.counts[tile] > 0 {
+			bonus = append(bonus, g.take(tile)...)
+		}
+	}
+	return bonus
+}
+
+// winningHand builds four sets and a pillow, or returns false when the wall
+// doesn't have the tiles for it.
+func (g *generator) winningHand() (score.Hand, bool) {
+	hand := score.Hand{}
+	for len(hand.Sets) < 4 {
+		set, ok := g.nextSet()
+		if !ok {
+			return hand, false
+		}
+		hand.Sets = append(hand.Sets, set)
+	}
+	pillow, ok := g.nextPillow()
+	if !ok {
+		return hand, false
+	}
+	hand.Sets = append(hand.Sets, pillow)
+
+	// Fully concealed hands are rare, so most hands have a few melded sets.
+	concealedChance := 2
+	if g.rng.Intn(5) == 0 {
+		concealedChance = 1
+	}
+	for idx := range hand.Sets[:4] {
+		hand.Sets[idx].Concealed = g.rng.Intn(concealedChance) == 0
+	}
+	return hand, true
+}
+
+func (g *generator) winConditions(hand *score.Hand) {
+	hand.WinSelfDrawn = g.rng.Intn(3) == 0
+	hand.LastChance = g.rng.Intn(10) == 0
+	if hand.WinSelfDrawn {
+		hand.WinOnReplacementTile = g.rng.Intn(10) == 0 && drawsReplacements(hand)
+		hand.LastTileOfWall = g.rng.Intn(20) == 0
+	} else {
+		hand.RobbedTheKong = g.rng.Intn(20) == 0
+	}
+}
+
+// drawsReplacements returns true when the hand got replacement tiles, for its
+// kongs or its flowers and seasons.
+func drawsReplacements(hand *score.Hand) bool {
+	if len(hand.Bonus) > 0 {
+		return true
+	}
+	for _, set := range hand.Sets {
+		if len(set.Tiles) == 4 {
+			return true
+		}
+	}
+	return false
+}
+
+// removeTile takes one tile out of a concealed pung, chow or the pillow,
+// so that the hand is ready rather than winning.
+func (g *generator) removeTile(hand *score.Hand) {
+	candidates := []int{}
+	for idx := range hand.Sets {
+		set := &hand.Sets[idx]
+		if set.Concealed && len(set.Tiles) < 4 {
+			candidates = append(candidates, idx)
+		}
+	}
+
+	set := &hand.Sets[candidates[g.rng.Intn(len(candidates))]]
+	remove := g.rng.Intn(len(set.Tiles))
+	set.Tiles = append(set.Tiles[:remove:remove], set.Tiles[remove+1:]...)
+}
+
+// Generate returns a random hand drawn from the wall with the given seed.
+// The same seed and options always give the same hand. It returns an error
+// when no hand could be built from the tiles of the walls it tried.
+func Generate(seed int64, options GenerateOptions) (score.Hand, error) {
+	rng := rand.New(rand.NewSource(seed))
+	suits := []score.Tile{score.Balls1.Suit(), score.Chars1.Suit(), score.Bamboo1.Suit()}
+	winds := []score.Tile{score.WindEast, score.WindSouth, score.WindWest, score.WindNorth}
+
+	for attempt := 0; ; attempt++ {
+		g := newGenerator(rng, New(rng.Int63()))
+		if options.Flush {
+			g.suit = suits[rng.Intn(len(suits))]
+		}
+
+		hand, ok := g.winningHand()
+		if !ok {
+			if attempt == maxAttempts {
+				return score.Hand{}, ErrNoHandGenerated
+			}
+			continue
+		}
+
+		hand.WindOwn = winds[rng.Intn(len(winds))]
+		hand.WindRound = winds[rng.Intn(len(winds))]
+		if options.Flowers {
+			hand.Bonus = g.bonusTiles(1 + rng.Intn(3))
+		}
+
+		switch options.Kind {
+		case WinningHand:
+			hand.Winning = true
+			g.winConditions(&hand)
+		case ReadyHand:
+			g.removeTile(&hand)
+		}
+		return hand, nil
+	}
+}
@@ -0,0 +1,121 @@
+package wall
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/sybrenstuvel/mahjong/score"
+	check "gopkg.in/check.v1"
+)
+
+type GenerateTestSuite struct{}
+
+var _ = check.Suite(&GenerateTestSuite{})
+
+// assertRealTiles checks that the hand doesn't use more of a tile than the game has.
+func assertRealTiles(c *check.C, hand *score.Hand) {
+	counts := map[score.Tile]int{}
+	for _, set := range hand.Sets {
+		for _, tile := range set.Tiles {
+			counts[tile]++
+		}
+	}
+	for _, tile := range hand.Bonus {
+		assert.True(c, tile.IsBonus())
+		counts[tile]++
+	}
+	for tile, count := range counts {
+		max := 4
+		if tile.IsBonus() {
+			max = 1
+		}
+		assert.True(c, count <= max, "%d times tile %d in %s", count, tile, score.FormatHand(hand))
+	}
+}
+
+func mustGenerate(c *check.C, seed int64, options GenerateOptions) score.Hand {
+	hand, err := Generate(seed, options)
+	if err != nil {
+		c.Fatalf("seed %d: %s", seed, err)
+	}
+	return hand
+}
+
+func (s *GenerateTestSuite) TestGenerateWinning(c *check.C) {
+	for seed := int64(0); seed < 50; seed++ {
+		hand := mustGenerate(c, seed, GenerateOptions{})
+		assertRealTiles(c, &hand)
+		assert.Empty(c, hand.Bonus)
+		assert.True(c, hand.WindOwn.IsWind())
+		assert.True(c, hand.WindRound.IsWind())
+
+		notation := score.FormatHand(&hand)
+		breakdown := score.ScoreBreakdown(&hand)
+		assert.True(c, breakdown.Winning, "seed %d: %s", seed, notation)
+	}
+}
+
+func (s *GenerateTestSuite) TestGenerateReady(c *check.C) {
+	for seed := int64(0); seed < 50; seed++ {
+		hand := mustGenerate(c, seed, GenerateOptions{Kind: ReadyHand})
+		assertRealTiles(c, &hand)
+
+		notation := score.FormatHand(&hand)
+		assert.False(c, score.ScoreBreakdown(&hand).Winning, "seed %d: %s", seed, notation)
+		assert.Equal(c, 0, score.Shanten(&hand), "seed %d: %s", seed, notation)
+		assert.NotEmpty(c, score.Waits(&hand), "seed %d: %s", seed, notation)
+	}
+}
+
+func (s *GenerateTestSuite) TestGenerateOptions(c *check.C) {
+	for seed := int64(0); seed < 50; seed++ {
+		hand := mustGenerate(c, seed, GenerateOptions{Flush: true, Flowers: true})
+		assertRealTiles(c, &hand)
+		assert.NotEmpty(c, hand.Bonus)
+
+		suit := hand.Sets[0].Tiles[0].Suit()
+		assert.NotEqual(c, score.NoTile, suit)
+		for _, set := range hand.Sets {
+			for _, tile := range set.Tiles {
+				assert.Equal(c, suit, tile.Suit(), "seed %d: %s", seed, score.FormatHand(&hand))
+			}
+		}
+	}
+}
+
+func (s *GenerateTestSuite) TestGenerateReplacementTile(c *check.C) {
+	replaced := 0
+	for seed := int64(0); seed < 200; seed++ {
+		for _, options := range []GenerateOptions{{}, {Flowers: true}} {
+			hand := mustGenerate(c, seed, options)
+			if !hand.WinOnReplacementTile {
+				continue
+			}
+			replaced++
+			assert.True(c, hand.WinSelfDrawn)
+
+			// Only kongs and flowers and seasons give replacement tiles.
+			kongs := 0
+			for _, set := range hand.Sets {
+				if len(set.Tiles) == 4 {
+					kongs++
+				}
+			}
+			assert.True(c, kongs > 0 || len(hand.Bonus) > 0, "seed %d: %s", seed, score.FormatHand(&hand))
+		}
+	}
+	assert.True(c, replaced > 0)
+}
+
+func (s *GenerateTestSuite) TestGenerateSeed(c *check.C) {
+	options := GenerateOptions{Flowers: true}
+	assert.Equal(c, mustGenerate(c, 47, options), mustGenerate(c, 47, options))
+	assert.NotEqual(c, mustGenerate(c, 47, options), mustGenerate(c, 48, options))
+}
+
+func (s *GenerateTestSuite) TestParseHandKind(c *check.C) {
+	kind, err := ParseHandKind("ready")
+	assert.Nil(c, err)
+	assert.Equal(c, ReadyHand, kind)
+
+	_, err = ParseHandKind("flush")
+	assert.Equal(c, ErrUnknownHandKind, err)
+}
@@ -0,0 +1,135 @@
+/*
+ * The wall of tiles that a hand is played from.
+ *
+ * All 144 tiles are shuffled with a seeded random number generator, so that
+ * a wall, and thereby a whole game, can be reproduced from its seed. The last
+ * tiles of the wall form the dead wall, from which replacement tiles are drawn
+ * after declaring a kong or a flower or season.
+ */
+
+package wall
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/sybrenstuvel/mahjong/score"
+)
+
+const (
+	// Size is the number of tiles in a full wall.
+	Size = 144
+	// DeadWallSize is the number of tiles kept aside for replacement draws.
+	DeadWallSize = 14
+)
+
+// Standard errors.
+var (
+	ErrWallEmpty = errors.New("no tiles left in the wall")
+)
+
+// AllTiles returns every tile of the game in tile order: four of each suited tile
+// and honour, and one of each flower and season.
+func AllTiles() []score.Tile {
+	tiles := make([]score.Tile, 0, Size)
+	for _, tile := range score.PlayingTiles() {
+		tiles = append(tiles, tile, tile, tile, tile)
+	}
+	for tile := score.Flower1; tile <= score.Flower4; tile++ {
+		tiles = append(tiles, tile)
+	}
+	for tile := score.Season1; tile <= score.Season4; tile++ {
+		tiles = append(tiles, tile)
+	}
+	return tiles
+}
+
+// Wall is a shuffled wall of tiles. Tiles are drawn from the front of the live
+// wall, and replacement tiles from the dead wall.
+type Wall struct {
+	seed int64
+	live []score.Tile
+	dead []score.Tile
+}
+
+// New returns a wall shuffled with the given seed. The same seed always gives the same wall.
+func New(seed int64) *Wall {
+	tiles := AllTiles()
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(tiles), func(i, j int) {
+		tiles[i], tiles[j] = tiles[j], tiles[i]
+	})
+
+	return &Wall{
+		seed: seed,
+		live: tiles[:Size-DeadWallSize],
+		dead: tiles[Size-DeadWallSize:],
+	}
+}
+
+// FromTiles returns a wall that deals the tiles in the given order, for replaying
+// games and for testing. The last DeadWallSize tiles form the dead wall.
+func FromTiles(tiles []score.Tile) *Wall {
+	tiles = append([]score.Tile{}, tiles...)
+	split := len(tiles) - DeadWallSize
+	if split < 0 {
+		split = 0
+	}
+	return &Wall{
+		live: tiles[:split],
+		dead: tiles[split:],
+	}
+}
+
+// RandomSeed returns a seed for a wall that is different every time.
+func RandomSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// Seed returns the seed the wall was shuffled with.
+func (w *Wall) Seed() int64 {
+	return w.seed
+}
+
+// Remaining returns the number of tiles that can still be drawn normally.
+func (w *Wall) Remaining() int {
+	return len(w.live)
+}
+
+// DeadRemaining returns the number of replacement tiles left in the dead wall.
+func (w *Wall) DeadRemaining() int {
+	return len(w.dead)
+}
+
+// IsEmpty returns true when no more tiles can be drawn normally, which ends the hand in a draw.
+func (w *Wall) IsEmpty() bool {
+	return len(w.live) == 0
+}
+
+// Draw takes the next tile from the live wall.
+func (w *Wall) Draw() (score.Tile, error) {
+	if len(w.live) == 0 {
+		return score.NoTile, ErrWallEmpty
+	}
+	tile := w.live[0]
+	w.live = w.live[1:]
+	return tile, nil
+}
+
+// DrawReplacement takes a replacement tile from the dead wall, after declaring a kong,
+// flower or season. The dead wall is replenished from the end of the live wall,
+// so that it keeps its size for as long as there are tiles left.
+func (w *Wall) DrawReplacement() (score.Tile, error) {
+	if len(w.dead) == 0 {
+		return score.NoTile, ErrWallEmpty
+	}
+	tile := w.dead[0]
+	w.dead = w.dead[1:]
+
+	if last := len(w.live) - 1; last >= 0 {
+		w.dead = append(w.dead, w.live[last])
+		w.live = w.live[:last]
+	}
+	return tile, nil
+}
@@ -0,0 +1,110 @@
+package wall
+
+import (
+	"sort"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sybrenstuvel/mahjong/score"
+	check "gopkg.in/check.v1"
+)
+
+type WallTestSuite struct{}
+
+var _ = check.Suite(&WallTestSuite{})
+
+// drawAll empties the wall, replacement tiles included.
+func drawAll(c *check.C, w *Wall) []score.Tile {
+	tiles := []score.Tile{}
+	for !w.IsEmpty() {
+		tile, err := w.Draw()
+		assert.Nil(c, err)
+		tiles = append(tiles, tile)
+	}
+	for w.DeadRemaining() > 0 {
+		tile, err := w.DrawReplacement()
+		assert.Nil(c, err)
+		tiles = append(tiles, tile)
+	}
+	return tiles
+}
+
+func (s *WallTestSuite) TestAllTiles(c *check.C) {
+	tiles := AllTiles()
+	assert.Len(c, tiles, Size)
+
+	counts := map[score.Tile]int{}
+	for _, tile := range tiles {
+		assert.True(c, tile.IsValid(), "tile %d", tile)
+		counts[tile]++
+	}
+	assert.Len(c, counts, 34+8)
+	assert.Equal(c, 4, counts[score.Balls1])
+	assert.Equal(c, 4, counts[score.DragonWhite])
+	assert.Equal(c, 1, counts[score.Flower1])
+	assert.Equal(c, 1, counts[score.Season4])
+}
+
+func (s *WallTestSuite) TestShuffle(c *check.C) {
+	w := New(47)
+	assert.Equal(c, int64(47), w.Seed())
+	assert.Equal(c, Size-DeadWallSize, w.Remaining())
+	assert.Equal(c, DeadWallSize, w.DeadRemaining())
+
+	// The same seed gives the same wall, another seed a different one.
+	tiles := drawAll(c, w)
+	assert.Equal(c, tiles, drawAll(c, New(47)))
+	assert.NotEqual(c, tiles, drawAll(c, New(48)))
+	assert.NotEqual(c, AllTiles(), tiles)
+
+	// Shuffling doesn't lose any tiles.
+	sort.Sort(score.ByTileOrder(tiles))
+	assert.Equal(c, AllTiles(), tiles)
+}
+
+func (s *WallTestSuite) TestDraw(c *check.C) {
+	w := New(1)
+	for idx := 0; idx < Size-DeadWallSize; idx++ {
+		_, err := w.Draw()
+		assert.Nil(c, err)
+	}
+	assert.True(c, w.IsEmpty())
+
+	tile, err := w.Draw()
+	assert.Equal(c, ErrWallEmpty, err)
+	assert.Equal(c, score.NoTile, tile)
+
+	// Replacement tiles can still be drawn from the dead wall.
+	_, err = w.DrawReplacement()
+	assert.Nil(c, err)
+	assert.Equal(c, DeadWallSize-1, w.DeadRemaining())
+}
+
+func (s *WallTestSuite) TestDrawReplacement(c *check.C) {
+	w := New(1)
+	tile, err := w.DrawReplacement()
+	assert.Nil(c, err)
+	assert.True(c, tile.IsValid())
+
+	// The dead wall is replenished from the live wall.
+	assert.Equal(c, DeadWallSize, w.DeadRemaining())
+	assert.Equal(c, Size-DeadWallSize-1, w.Remaining())
+
+	for w.DeadRemaining() > 0 {
+		_, err = w.DrawReplacement()
+		assert.Nil(c, err)
+	}
+	assert.True(c, w.IsEmpty())
+	_, err = w.DrawReplacement()
+	assert.Equal(c, ErrWallEmpty, err)
+}
+
+func (s *WallTestSuite) TestFromTiles(c *check.C) {
+	tiles := AllTiles()
+	w := FromTiles(tiles)
+	assert.Equal(c, tiles, drawAll(c, w))
+
+	// Short walls only have a dead wall.
+	w = FromTiles(tiles[:3])
+	assert.True(c, w.IsEmpty())
+	assert.Equal(c, 3, w.DeadRemaining())
+}
@@ -1,6 +1,22 @@
 package web
 
-// Score represents a single score (like of a hand).
+import (
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/storage"
+)
+
+// Score represents a single score (like of a hand), and how it was calculated.
+// The ID is that of the recorded hand, or 0 when it wasn't recorded.
 type Score struct {
-	Score int `json:"score"`
+	Score     int             `json:"score"`
+	Breakdown score.Breakdown `json:"breakdown"`
+	ID        storage.ID      `json:"id"`
+}
+
+// Settlement contains the score of each player's hand, and what they gain (positive) or pay (negative).
+// The ID is that of the recorded settlement, or 0 when it couldn't be recorded.
+type Settlement struct {
+	Scores   [score.NumPlayers]int `json:"scores"`
+	Payments [score.NumPlayers]int `json:"payments"`
+	ID       storage.ID            `json:"id"`
 }
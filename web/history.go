@@ -0,0 +1,337 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/storage"
+)
+
+// SessionHistory is a session with everything that was recorded during it.
+type SessionHistory struct {
+	storage.Session
+	Hands       []storage.Hand       `json:"hands"`
+	Settlements []storage.Settlement `json:"settlements"`
+}
+
+// RescoreResult tells how many hands got a different score after rescoring.
+type RescoreResult struct {
+	RuleSet string `json:"ruleset"`
+	Changed int    `json:"changed"`
+}
+
+// storageError writes an Internal Server Error status.
+func storageError(w http.ResponseWriter, err error, logger *log.Entry) {
+	logger.WithError(err).Error("storage error")
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprintf(w, "Storage error: %s\n", err)
+}
+
+// parseID parses an ID, and writes a Bad Request status if it fails.
+// An empty string is ID 0, which means "none".
+func parseID(w http.ResponseWriter, value, what string, logger *log.Entry) (storage.ID, error) {
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		logger.WithError(err).Warningf("invalid %s", what)
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Invalid %s: %s\n", what, err)
+		return 0, err
+	}
+	return storage.ID(id), nil
+}
+
+// checkRecordedBy finds the session and players that a hand or settlement is recorded for,
+// and writes a Bad Request status if one of them doesn't exist. IDs of 0 are not checked.
+func (p *Pages) checkRecordedBy(w http.ResponseWriter, session storage.ID, players []storage.ID,
+	logger *log.Entry) error {
+	var err error
+	if session != 0 {
+		_, err = p.store.Session(session)
+	}
+	for _, player := range players {
+		if err == nil && player != 0 {
+			_, err = p.store.Player(player)
+		}
+	}
+	if err != nil {
+		logger.WithError(err).Warning("unable to record")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unable to record: %s\n", err)
+	}
+	return err
+}
+
+// recordHand stores the scored hand, and returns its ID. Scoring shouldn't fail
+// because of the storage, so errors are only logged, and return ID 0.
+func (p *Pages) recordHand(hand storage.Hand, logger *log.Entry) storage.ID {
+	if err := p.store.AddHand(&hand); err != nil {
+		logger.WithError(err).Error("unable to record hand")
+		return 0
+	}
+	return hand.ID
+}
+
+// recordSettlement stores the settlement along with the scored hands, if any, and returns
+// its ID. Like recordHand, errors are only logged, and return ID 0.
+func (p *Pages) recordSettlement(request *SettleRequest, rules *score.RuleSet,
+	scored *[score.NumPlayers]storage.Hand, settlement score.Settlement,
+	payments [score.NumPlayers]int, logger *log.Entry) storage.ID {
+	record := storage.Settlement{
+		Session:    request.Session,
+		RuleSet:    rules.Name,
+		Players:    request.Players,
+		Settlement: settlement,
+		Payments:   payments,
+	}
+	if scored != nil {
+		for player, hand := range scored {
+			if record.Hands[player] = p.recordHand(hand, logger); record.Hands[player] == 0 {
+				return 0
+			}
+		}
+	}
+	if err := p.store.AddSettlement(&record); err != nil {
+		logger.WithError(err).Error("unable to record settlement")
+		return 0
+	}
+	return record.ID
+}
+
+func (p *Pages) apiPlayers(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	players, err := p.store.Players()
+	if err != nil {
+		storageError(w, err, logger)
+		return
+	}
+	replyJSON(w, players, logger)
+}
+
+func (p *Pages) apiAddPlayer(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	player := storage.Player{}
+	if DecodeJSON(w, r.Body, &player, logger) != nil {
+		return
+	}
+
+	player.ID = 0
+	if err := p.store.AddPlayer(&player); err != nil {
+		logger.WithError(err).Warning("unable to add player")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unable to add player: %s\n", err)
+		return
+	}
+	logger.WithFields(log.Fields{"player": player.ID, "name": player.Name}).Info("added player")
+	w.WriteHeader(http.StatusCreated)
+	replyJSON(w, &player, logger)
+}
+
+// DateFormat is how dates are given in query parameters.
+const DateFormat = "2006-01-02"
+
+// statsFilter reads the hands to compute statistics over from the query parameters
+// 'since' and 'until', which are dates, and 'ruleset'. It writes a Bad Request status
+// if it fails.
+func statsFilter(w http.ResponseWriter, r *http.Request, logger *log.Entry) (storage.HandFilter, error) {
+	query := r.URL.Query()
+	filter := storage.HandFilter{RuleSet: query.Get("ruleset")}
+	for _, param := range []struct {
+		name string
+		date *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse(DateFormat, value)
+		if err != nil {
+			logger.WithError(err).Warningf("invalid %s", param.name)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Invalid %s: %s\n", param.name, err)
+			return filter, err
+		}
+		*param.date = date
+	}
+	return filter, nil
+}
+
+// playerStats computes the statistics of the player in the URL, over the hands
+// selected by the query parameters. It writes an error status if it fails.
+func (p *Pages) playerStats(w http.ResponseWriter, r *http.Request, logger *log.Entry) (*storage.PlayerStats, error) {
+	id, err := parseID(w, mux.Vars(r)["player-id"], "player", logger)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := statsFilter(w, r, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := storage.ComputePlayerStats(p.store, id, filter)
+	if err != nil {
+		logger.WithError(err).Warning("unable to compute player statistics")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "Unable to compute player statistics: %s\n", err)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// apiPlayerStats gives the statistics of a player. The 'since', 'until' and 'ruleset'
+// query parameters limit them to a season or rule set.
+func (p *Pages) apiPlayerStats(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	stats, err := p.playerStats(w, r, logger)
+	if err != nil {
+		return
+	}
+	replyJSON(w, stats, logger)
+}
+
+func (p *Pages) showPlayersPage(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	players, err := p.store.Players()
+	if err != nil {
+		storageError(w, err, logger)
+		return
+	}
+	p.showTemplate("templates/players.html", w, r, TemplateData{
+		"Players": players,
+	})
+}
+
+func (p *Pages) showPlayerPage(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	stats, err := p.playerStats(w, r, logger)
+	if err != nil {
+		return
+	}
+	query := r.URL.Query()
+	p.showTemplate("templates/player.html", w, r, TemplateData{
+		"Stats": stats,
+		"Since": query.Get("since"),
+		"Until": query.Get("until"),
+	})
+}
+
+func (p *Pages) apiSessions(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	sessions, err := p.store.Sessions()
+	if err != nil {
+		storageError(w, err, logger)
+		return
+	}
+	replyJSON(w, sessions, logger)
+}
+
+// apiAddSession starts a session. It is played with the default rule set, unless it says otherwise.
+func (p *Pages) apiAddSession(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	session := storage.Session{}
+	if DecodeJSON(w, r.Body, &session, logger) != nil {
+		return
+	}
+
+	rules, err := p.lookupRuleSet(w, session.RuleSet, logger)
+	if err != nil {
+		return
+	}
+	session.ID = 0
+	session.RuleSet = rules.Name
+	if err := p.store.AddSession(&session); err != nil {
+		logger.WithError(err).Warning("unable to add session")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unable to add session: %s\n", err)
+		return
+	}
+	logger.WithFields(log.Fields{"session": session.ID, "name": session.Name}).Info("added session")
+	w.WriteHeader(http.StatusCreated)
+	replyJSON(w, &session, logger)
+}
+
+func (p *Pages) apiSession(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	id, err := parseID(w, mux.Vars(r)["session-id"], "session", logger)
+	if err != nil {
+		return
+	}
+
+	session, err := p.store.Session(id)
+	if err != nil {
+		logger.WithError(err).Warning("unable to find session")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "Unable to find session: %s\n", err)
+		return
+	}
+	history := SessionHistory{Session: *session}
+	if history.Hands, err = p.store.Hands(storage.HandFilter{Session: id}); err != nil {
+		storageError(w, err, logger)
+		return
+	}
+	if history.Settlements, err = p.store.Settlements(id); err != nil {
+		storageError(w, err, logger)
+		return
+	}
+	replyJSON(w, &history, logger)
+}
+
+// apiHands lists the recorded hands. The 'session', 'player' and 'ruleset' query
+// parameters select which ones.
+func (p *Pages) apiHands(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	query := r.URL.Query()
+	filter := storage.HandFilter{RuleSet: query.Get("ruleset")}
+	var err error
+	if filter.Session, err = parseID(w, query.Get("session"), "session", logger); err != nil {
+		return
+	}
+	if filter.Player, err = parseID(w, query.Get("player"), "player", logger); err != nil {
+		return
+	}
+
+	hands, err := p.store.Hands(filter)
+	if err != nil {
+		storageError(w, err, logger)
+		return
+	}
+	replyJSON(w, hands, logger)
+}
+
+// apiRescore scores the recorded hands of a rule set again, after its rules changed.
+func (p *Pages) apiRescore(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	rules, err := p.lookupRuleSet(w, r.URL.Query().Get("ruleset"), logger)
+	if err != nil {
+		return
+	}
+
+	changed, err := storage.Rescore(p.store, rules)
+	if err != nil {
+		storageError(w, err, logger)
+		return
+	}
+	logger.WithFields(log.Fields{"ruleset": rules.Name, "changed": changed}).Info("rescored hands")
+	replyJSON(w, &RescoreResult{rules.Name, changed}, logger)
+}
+
+// addHistoryRoutes adds routes to look back at the recorded history.
+func (p *Pages) addHistoryRoutes(router *mux.Router) {
+	router.HandleFunc("/api/players", p.apiPlayers).Methods("GET")
+	router.HandleFunc("/api/players", p.apiAddPlayer).Methods("POST")
+	router.HandleFunc("/api/players/{player-id}/stats", p.apiPlayerStats).Methods("GET")
+	router.HandleFunc("/players", p.showPlayersPage).Methods("GET")
+	router.HandleFunc("/players/{player-id}", p.showPlayerPage).Methods("GET")
+	router.HandleFunc("/api/sessions", p.apiSessions).Methods("GET")
+	router.HandleFunc("/api/sessions", p.apiAddSession).Methods("POST")
+	router.HandleFunc("/api/sessions/{session-id}", p.apiSession).Methods("GET")
+	router.HandleFunc("/api/hands", p.apiHands).Methods("GET")
+	router.HandleFunc("/api/rescore", p.apiRescore).Methods("POST")
+}
@@ -0,0 +1,254 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"github.com/sybrenstuvel/mahjong/league"
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/storage"
+	"github.com/sybrenstuvel/mahjong/wall"
+)
+
+// TournamentRequest creates a tournament between registered players.
+type TournamentRequest struct {
+	Name    string               `json:"name"`
+	RuleSet string               `json:"ruleset"`
+	Players []storage.ID         `json:"players"`
+	Ranking storage.RankingRules `json:"ranking"`
+}
+
+// ResultRequest gives the points the players at a table ended their match with,
+// in the order they are seated.
+type ResultRequest struct {
+	Scores [score.NumPlayers]int `json:"scores"`
+}
+
+// TournamentStandings is a tournament along with its current standings.
+type TournamentStandings struct {
+	storage.Tournament
+	Standings []league.Standing `json:"standings"`
+	Complete  bool              `json:"complete"`
+}
+
+func (p *Pages) apiTournaments(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	tournaments, err := p.store.Tournaments()
+	if err != nil {
+		storageError(w, err, logger)
+		return
+	}
+	replyJSON(w, tournaments, logger)
+}
+
+// apiAddTournament creates a tournament. It is played with the default rule set,
+// and ranked by raw points, unless it says otherwise.
+func (p *Pages) apiAddTournament(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	request := TournamentRequest{}
+	if DecodeJSON(w, r.Body, &request, logger) != nil {
+		return
+	}
+	rules, err := p.lookupRuleSet(w, request.RuleSet, logger)
+	if err != nil {
+		return
+	}
+
+	tournament, err := league.New(request.Name, rules.Name, request.Players, request.Ranking)
+	if err == nil {
+		err = p.store.AddTournament(tournament)
+	}
+	if err != nil {
+		logger.WithError(err).Warning("unable to add tournament")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unable to add tournament: %s\n", err)
+		return
+	}
+	logger.WithFields(log.Fields{"tournament": tournament.ID, "name": tournament.Name}).Info("added tournament")
+	w.WriteHeader(http.StatusCreated)
+	replyJSON(w, tournament, logger)
+}
+
+// findTournament returns the tournament in the URL, and writes an error status if it fails.
+func (p *Pages) findTournament(w http.ResponseWriter, r *http.Request, logger *log.Entry) (*storage.Tournament, error) {
+	id, err := parseID(w, mux.Vars(r)["tournament-id"], "tournament", logger)
+	if err != nil {
+		return nil, err
+	}
+	tournament, err := p.store.Tournament(id)
+	if err != nil {
+		logger.WithError(err).Warning("unable to find tournament")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "Unable to find tournament: %s\n", err)
+		return nil, err
+	}
+	return tournament, nil
+}
+
+// standings returns the tournament with its standings, and writes an error status if it fails.
+func standings(w http.ResponseWriter, tournament *storage.Tournament, logger *log.Entry) (*TournamentStandings, error) {
+	standings, err := league.Standings(tournament)
+	if err != nil {
+		logger.WithError(err).Error("unable to compute standings")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "Unable to compute standings: %s\n", err)
+		return nil, err
+	}
+	return &TournamentStandings{*tournament, standings, league.Complete(tournament)}, nil
+}
+
+func (p *Pages) apiTournament(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	tournament, err := p.findTournament(w, r, logger)
+	if err != nil {
+		return
+	}
+	document, err := standings(w, tournament, logger)
+	if err != nil {
+		return
+	}
+	replyJSON(w, document, logger)
+}
+
+// apiAddRound seats the players for the next round. The 'seed' query parameter
+// reproduces an earlier seating.
+func (p *Pages) apiAddRound(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	seed := wall.RandomSeed()
+	if value := r.URL.Query().Get("seed"); value != "" {
+		var err error
+		if seed, err = strconv.ParseInt(value, 10, 64); err != nil {
+			logger.WithError(err).Warning("invalid seed")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Invalid seed: %s\n", err)
+			return
+		}
+	}
+
+	p.leagueMutex.Lock()
+	defer p.leagueMutex.Unlock()
+	tournament, err := p.findTournament(w, r, logger)
+	if err != nil {
+		return
+	}
+	round, err := league.AddRound(tournament, seed)
+	if err == nil {
+		err = p.store.UpdateTournament(tournament)
+	}
+	if err != nil {
+		storageError(w, err, logger)
+		return
+	}
+
+	logger.WithFields(log.Fields{
+		"tournament": tournament.ID,
+		"round":      len(tournament.Rounds),
+		"seed":       seed,
+	}).Info("seated new round")
+	w.WriteHeader(http.StatusCreated)
+	replyJSON(w, round, logger)
+}
+
+// apiRecordResult records the result of a table. Rounds and tables in the URL are
+// counted from 1. It replies with the new standings.
+func (p *Pages) apiRecordResult(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	vars := mux.Vars(r)
+	round, err := strconv.Atoi(vars["round"])
+	if err != nil {
+		logger.WithError(err).Warning("invalid round")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Invalid round: %s\n", err)
+		return
+	}
+	table, err := strconv.Atoi(vars["table"])
+	if err != nil {
+		logger.WithError(err).Warning("invalid table")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Invalid table: %s\n", err)
+		return
+	}
+	request := ResultRequest{}
+	if DecodeJSON(w, r.Body, &request, logger) != nil {
+		return
+	}
+
+	p.leagueMutex.Lock()
+	defer p.leagueMutex.Unlock()
+	tournament, err := p.findTournament(w, r, logger)
+	if err != nil {
+		return
+	}
+	if err := league.RecordResult(tournament, round-1, table-1, request.Scores); err != nil {
+		logger.WithError(err).Warning("unable to record result")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "Unable to record result: %s\n", err)
+		return
+	}
+	if err := p.store.UpdateTournament(tournament); err != nil {
+		storageError(w, err, logger)
+		return
+	}
+
+	logger.WithFields(log.Fields{
+		"tournament": tournament.ID,
+		"round":      round,
+		"table":      table,
+	}).Info("recorded result")
+	document, err := standings(w, tournament, logger)
+	if err != nil {
+		return
+	}
+	replyJSON(w, document, logger)
+}
+
+func (p *Pages) showTournamentsPage(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	tournaments, err := p.store.Tournaments()
+	if err != nil {
+		storageError(w, err, logger)
+		return
+	}
+	p.showTemplate("templates/tournaments.html", w, r, TemplateData{
+		"Tournaments": tournaments,
+	})
+}
+
+func (p *Pages) showTournamentPage(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	tournament, err := p.findTournament(w, r, logger)
+	if err != nil {
+		return
+	}
+	document, err := standings(w, tournament, logger)
+	if err != nil {
+		return
+	}
+
+	// The template shows names rather than IDs.
+	names := map[storage.ID]string{}
+	for _, id := range tournament.Players {
+		if player, err := p.store.Player(id); err == nil {
+			names[id] = player.Name
+		}
+	}
+	p.showTemplate("templates/tournament.html", w, r, TemplateData{
+		"Tournament": document,
+		"Names":      names,
+	})
+}
+
+// addLeagueRoutes adds routes to run tournaments.
+func (p *Pages) addLeagueRoutes(router *mux.Router) {
+	router.HandleFunc("/api/tournaments", p.apiTournaments).Methods("GET")
+	router.HandleFunc("/api/tournaments", p.apiAddTournament).Methods("POST")
+	router.HandleFunc("/api/tournaments/{tournament-id}", p.apiTournament).Methods("GET")
+	router.HandleFunc("/api/tournaments/{tournament-id}/rounds", p.apiAddRound).Methods("POST")
+	router.HandleFunc("/api/tournaments/{tournament-id}/rounds/{round}/tables/{table}",
+		p.apiRecordResult).Methods("PUT")
+	router.HandleFunc("/tournaments", p.showTournamentsPage).Methods("GET")
+	router.HandleFunc("/tournaments/{tournament-id}", p.showTournamentPage).Methods("GET")
+}
@@ -0,0 +1,216 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+	"github.com/sybrenstuvel/mahjong/bot"
+	"github.com/sybrenstuvel/mahjong/game"
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/table"
+	"github.com/sybrenstuvel/mahjong/wall"
+)
+
+const (
+	// Time allowed to write a message to the client.
+	writeWait = 10 * time.Second
+	// Time allowed between pongs from the client.
+	pongWait = 60 * time.Second
+	// Pings are sent a bit more often than pongs are expected.
+	pingPeriod = pongWait * 9 / 10
+	// Actions are tiny; anything bigger is not from a well-behaved client.
+	maxActionSize = 1024
+)
+
+// Tables lets players play at tables, over WebSocket connections.
+type Tables struct {
+	lobby          *table.Lobby
+	defaultRuleSet string
+	upgrader       websocket.Upgrader
+}
+
+// CreateTableHandler creates a new Tables object. New tables play with the
+// default rule set, unless the request asks for another one.
+func CreateTableHandler(lobby *table.Lobby, defaultRuleSet string) *Tables {
+	return &Tables{
+		lobby:          lobby,
+		defaultRuleSet: defaultRuleSet,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 4096,
+		},
+	}
+}
+
+func (ts *Tables) apiTables(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	replyJSON(w, ts.lobby.Tables(), logger)
+}
+
+// apiOpenTable opens a new table. The 'ruleset' query parameter chooses the rules to
+// play with, and 'bots' is a comma-separated list of difficulties of bots to seat.
+func (ts *Tables) apiOpenTable(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+
+	difficulties := []bot.Difficulty{}
+	if bots := r.URL.Query().Get("bots"); bots != "" {
+		for _, name := range strings.Split(bots, ",") {
+			difficulty, err := bot.ParseDifficulty(strings.TrimSpace(name))
+			if err != nil {
+				logger.WithError(err).Warning("invalid bot")
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, "Invalid bot: %s\n", err)
+				return
+			}
+			difficulties = append(difficulties, difficulty)
+		}
+	}
+	if len(difficulties) >= score.NumPlayers {
+		logger.WithField("bots", len(difficulties)).Warning("too many bots")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Too many bots: at most %d seats can have a bot\n", score.NumPlayers-1)
+		return
+	}
+
+	name := r.URL.Query().Get("ruleset")
+	if name == "" {
+		name = ts.defaultRuleSet
+	}
+	rules, err := score.LookupRuleSet(name)
+	if err != nil {
+		logger.WithError(err).Warning("unable to find rule set")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unable to find rule set: %s\n", err)
+		return
+	}
+
+	t := ts.lobby.Open(rules)
+	for idx, difficulty := range difficulties {
+		name := fmt.Sprintf("Bot %d (%s)", idx+1, difficulty)
+		if _, err := t.AddBot(name, bot.New(difficulty, wall.RandomSeed())); err != nil {
+			logger.WithError(err).Error("unable to seat bot")
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "Unable to seat bot: %s\n", err)
+			return
+		}
+	}
+	logger.WithFields(log.Fields{"table": t.ID, "ruleset": rules.Name, "bots": len(difficulties)}).Info("opened table")
+	w.WriteHeader(http.StatusCreated)
+	replyJSON(w, t.Info(), logger)
+}
+
+// joinTable seats the player at the table, and connects them to it over a WebSocket.
+// The player's name is given in the 'name' query parameter. The client sends
+// actions as JSON, and receives table.Message documents.
+func (ts *Tables) joinTable(w http.ResponseWriter, r *http.Request) {
+	tableID := mux.Vars(r)["table-id"]
+	name := r.URL.Query().Get("name")
+	logger := log.WithFields(log.Fields{
+		"addr":   r.RemoteAddr,
+		"table":  tableID,
+		"player": name,
+	})
+
+	t, err := ts.lobby.Lookup(tableID)
+	if err != nil {
+		logger.WithError(err).Warning("unable to find table")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "Unable to find table: %s\n", err)
+		return
+	}
+	if name == "" {
+		logger.Warning("no name given")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unable to join table: %s\n", table.ErrNoName)
+		return
+	}
+
+	conn, err := ts.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already responded to the client.
+		logger.WithError(err).Warning("unable to upgrade to WebSocket")
+		return
+	}
+
+	player, err := t.Join(name)
+	if err != nil {
+		logger.WithError(err).Warning("unable to join table")
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()),
+			time.Now().Add(writeWait))
+		conn.Close()
+		return
+	}
+
+	logger = logger.WithField("seat", player.Seat)
+	logger.Info("player connected")
+	go readActions(conn, player, logger)
+	writeMessages(conn, player, logger)
+}
+
+// readActions passes the actions the client sends on to the table, until the connection closes.
+func readActions(conn *websocket.Conn, player *table.Player, logger *log.Entry) {
+	defer player.Leave()
+
+	conn.SetReadLimit(maxActionSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		action := game.Action{}
+		if err := conn.ReadJSON(&action); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				logger.WithError(err).Warning("connection lost")
+			}
+			return
+		}
+		player.Act(action)
+	}
+}
+
+// writeMessages sends what the table has to say to the client, until the player
+// leaves the table or the connection breaks.
+func writeMessages(conn *websocket.Conn, player *table.Player, logger *log.Entry) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+		player.Leave()
+		logger.Info("player disconnected")
+	}()
+
+	for {
+		select {
+		case message, ok := <-player.Messages():
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, "left the table"))
+				return
+			}
+			if err := conn.WriteJSON(message); err != nil {
+				logger.WithError(err).Warning("unable to send message")
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// AddRoutes adds routes to list, open and join tables.
+func (ts *Tables) AddRoutes(router *mux.Router) {
+	router.HandleFunc("/api/tables", ts.apiTables).Methods("GET")
+	router.HandleFunc("/api/tables", ts.apiOpenTable).Methods("POST")
+	router.HandleFunc("/tables/{table-id}/ws", ts.joinTable).Methods("GET")
+}
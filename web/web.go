@@ -1,31 +1,46 @@
 package web
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"html/template"
-	"mahjong/score"
-	"math/rand"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
+	"github.com/sybrenstuvel/mahjong/render"
+	"github.com/sybrenstuvel/mahjong/score"
+	"github.com/sybrenstuvel/mahjong/storage"
+	"github.com/sybrenstuvel/mahjong/wall"
 )
 
 // Pages handles web pages
 type Pages struct {
-	appVersion string
-	root       string
+	appVersion     string
+	root           string
+	defaultRuleSet string
+	store          storage.Store
+	leagueMutex    sync.Mutex // Serialises changes to tournaments.
 }
 
 // TemplateData is the mapping type we use to pass data to the template engine.
 type TemplateData map[string]interface{}
 
 // CreatePageHandler creates a new Pages object.
-func CreatePageHandler(appVersion string) *Pages {
+// Hands are scored with the default rule set, unless a request asks for another one.
+// Scored hands and settlements are recorded in the store.
+func CreatePageHandler(appVersion, defaultRuleSet string, store storage.Store) *Pages {
 	return &Pages{
-		appVersion,
-		TemplatePathPrefix("templates/layout.html"),
+		appVersion:     appVersion,
+		root:           TemplatePathPrefix("templates/layout.html"),
+		defaultRuleSet: defaultRuleSet,
+		store:          store,
 	}
 }
 
@@ -34,52 +49,430 @@ func (p *Pages) showIndexPage(w http.ResponseWriter, r *http.Request) {
 }
 
 func (p *Pages) showScorePage(w http.ResponseWriter, r *http.Request) {
-	p.showTemplate("templates/score.html", w, r, TemplateData{})
+	p.showTemplate("templates/score.html", w, r, TemplateData{
+		"DefaultRuleSet": p.defaultRuleSet,
+	})
 }
 
+// apiRandom generates a random hand. The query parameters determine what kind of hand:
+// 'kind' is "winning" or "ready", 'flush' and 'flowers' are booleans, and 'seed'
+// reproduces an earlier hand. The seed of the hand is sent in the X-Seed header.
 func (p *Pages) apiRandom(w http.ResponseWriter, r *http.Request) {
-	randWind := func() score.Tile {
-		return score.Tile(int(score.WindEast) + rand.Intn(4))
-	}
-	hand := score.Hand{
-		Sets: []score.Set{
-			score.Set{
-				Tiles:     []score.Tile{score.Balls1, score.Balls2, score.Balls3},
-				Concealed: false,
-			},
-			score.Set{
-				Tiles:     []score.Tile{score.DragonGreen, score.DragonGreen, score.DragonGreen, score.DragonGreen},
-				Concealed: true,
-			},
-		},
-		WindOwn:   randWind(),
-		WindRound: randWind(),
+	logger := log.WithField("addr", r.RemoteAddr)
+	query := r.URL.Query()
+	badRequest := func(err error, what string) {
+		logger.WithError(err).Warningf("invalid %s", what)
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Invalid %s: %s\n", what, err)
 	}
 
-	logger := log.WithField("addr", r.RemoteAddr)
+	options := wall.GenerateOptions{}
+	var err error
+	if kind := query.Get("kind"); kind != "" {
+		if options.Kind, err = wall.ParseHandKind(kind); err != nil {
+			badRequest(err, "kind")
+			return
+		}
+	}
+	if flush := query.Get("flush"); flush != "" {
+		if options.Flush, err = strconv.ParseBool(flush); err != nil {
+			badRequest(err, "flush")
+			return
+		}
+	}
+	if flowers := query.Get("flowers"); flowers != "" {
+		if options.Flowers, err = strconv.ParseBool(flowers); err != nil {
+			badRequest(err, "flowers")
+			return
+		}
+	}
+	seed := wall.RandomSeed()
+	if query.Get("seed") != "" {
+		if seed, err = strconv.ParseInt(query.Get("seed"), 10, 64); err != nil {
+			badRequest(err, "seed")
+			return
+		}
+	}
+
+	hand, err := wall.Generate(seed, options)
+	if err != nil {
+		logger.WithError(err).WithField("seed", seed).Error("unable to generate random hand")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "Unable to generate hand: %s\n", err)
+		return
+	}
+	logger.WithField("seed", seed).Debug("generated random hand")
+	w.Header().Set("X-Seed", strconv.FormatInt(seed, 10))
 	replyJSON(w, &hand, logger)
 }
 
+// ScoreRequest is a hand to score, along with the name of the rule set to score it with.
+// The limit is optional, and overrides the limit of the rule set; 0 means no limit.
+// The session and player are optional, and tell whose hand is recorded.
+type ScoreRequest struct {
+	score.Hand
+	RuleSet string     `json:"ruleset"`
+	Limit   *int       `json:"limit"`
+	Session storage.ID `json:"session"`
+	Player  storage.ID `json:"player"`
+}
+
+// decodeHand reads a hand from the request body, either as JSON or in the compact
+// notation, and writes a Bad Request status if it fails. The JSON may contain more
+// fields than just the hand, which are decoded into 'document'.
+func decodeHand(w http.ResponseWriter, r *http.Request, hand *score.Hand, document interface{},
+	logger *log.Entry) error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logger.WithError(err).Warning("unable to read request body")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unable to read request body: %s\n", err)
+		return err
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '{' {
+		return DecodeJSON(w, bytes.NewReader(body), document, logger)
+	}
+
+	*hand, err = score.ParseHand(string(body))
+	if err != nil {
+		logger.WithError(err).Warning("unable to parse hand notation")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unable to parse hand: %s\n", err)
+		return err
+	}
+	return nil
+}
+
+// lookupRuleSet finds the rule set by name, and writes a Bad Request status if it fails.
+// The default rule set is returned for an empty name.
+func (p *Pages) lookupRuleSet(w http.ResponseWriter, name string, logger *log.Entry) (*score.RuleSet, error) {
+	if name == "" {
+		name = p.defaultRuleSet
+	}
+
+	rules, err := score.LookupRuleSet(name)
+	if err != nil {
+		logger.WithError(err).Warning("unable to find rule set")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unable to find rule set: %s\n", err)
+		return nil, err
+	}
+	return rules, nil
+}
+
+// withLimit returns the rule set with the requested limit, or the rule set itself when
+// no limit was requested. It writes a Bad Request status for negative limits.
+func withLimit(w http.ResponseWriter, rules *score.RuleSet, limit *int, logger *log.Entry) (*score.RuleSet, error) {
+	if limit == nil {
+		return rules, nil
+	}
+	if *limit < 0 {
+		err := fmt.Errorf("limit must not be negative, not %d", *limit)
+		logger.WithError(err).Warning("invalid limit")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Invalid limit: %s\n", err)
+		return nil, err
+	}
+	return rules.WithLimit(*limit), nil
+}
+
+// decodeScoreRequest reads a hand from the request, and finds the rule set to score it with.
+// The rule set, limit, session and player can be given as query parameters, or as fields
+// of the JSON document. It writes a Bad Request status if it fails.
+func (p *Pages) decodeScoreRequest(w http.ResponseWriter, r *http.Request, logger *log.Entry) (
+	*ScoreRequest, *score.RuleSet, error) {
+	query := r.URL.Query()
+	request := ScoreRequest{RuleSet: query.Get("ruleset")}
+	if query.Get("limit") != "" {
+		limit, err := strconv.Atoi(query.Get("limit"))
+		if err != nil {
+			logger.WithError(err).Warning("invalid limit")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Invalid limit: %s\n", err)
+			return nil, nil, err
+		}
+		request.Limit = &limit
+	}
+	var err error
+	if request.Session, err = parseID(w, query.Get("session"), "session", logger); err != nil {
+		return nil, nil, err
+	}
+	if request.Player, err = parseID(w, query.Get("player"), "player", logger); err != nil {
+		return nil, nil, err
+	}
+	if err := decodeHand(w, r, &request.Hand, &request, logger); err != nil {
+		return nil, nil, err
+	}
+
+	rules, err := p.lookupRuleSet(w, request.RuleSet, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rules, err = withLimit(w, rules, request.Limit, logger); err != nil {
+		return nil, nil, err
+	}
+	return &request, rules, nil
+}
+
+// apiCalcScore scores the hand, and records it. The 'record' query parameter can
+// be set to false to only score the hand, like when previewing the score while
+// building a hand.
 func (p *Pages) apiCalcScore(w http.ResponseWriter, r *http.Request) {
 	logger := log.WithField("addr", r.RemoteAddr)
-	hand := score.Hand{}
-	if DecodeJSON(w, r.Body, &hand, logger) != nil {
+	record := true
+	if value := r.URL.Query().Get("record"); value != "" {
+		var err error
+		if record, err = strconv.ParseBool(value); err != nil {
+			logger.WithError(err).Warning("invalid record")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Invalid record: %s\n", err)
+			return
+		}
+	}
+	request, rules, err := p.decodeScoreRequest(w, r, logger)
+	if err != nil {
+		return
+	}
+	if p.checkRecordedBy(w, request.Session, []storage.ID{request.Player}, logger) != nil {
 		return
 	}
 
+	breakdown := rules.ScoreBreakdown(&request.Hand)
 	handScore := Score{
-		score.Score(&hand),
+		Score:     breakdown.Score,
+		Breakdown: breakdown,
+	}
+	if record {
+		handScore.ID = p.recordHand(storage.Hand{
+			Session:   request.Session,
+			Player:    request.Player,
+			RuleSet:   rules.Name,
+			Limit:     request.Limit,
+			Hand:      request.Hand,
+			Score:     breakdown.Score,
+			Breakdown: breakdown,
+		}, logger)
 	}
 
 	replyJSON(w, &handScore, logger)
 }
 
+func (p *Pages) apiWaits(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	request, rules, err := p.decodeScoreRequest(w, r, logger)
+	if err != nil {
+		return
+	}
+
+	replyJSON(w, rules.Waits(&request.Hand), logger)
+}
+
+// AdviseRequest is a hand to advise a discard for, along with the tiles that are visible
+// outside of it, like discards and sets melded by other players. The hand is either a string
+// in the compact notation or a hand document; the visible tiles are in the notation.
+type AdviseRequest struct {
+	Hand    json.RawMessage `json:"hand"`
+	Visible string          `json:"visible"`
+}
+
+func (p *Pages) apiAdvise(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	request := AdviseRequest{}
+	if DecodeJSON(w, r.Body, &request, logger) != nil {
+		return
+	}
+
+	hand, err := parseHandDocument(request.Hand)
+	if err != nil {
+		logger.WithError(err).Warning("unable to parse hand")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unable to parse hand: %s\n", err)
+		return
+	}
+	visible, err := score.ParseTiles(request.Visible)
+	if err != nil {
+		logger.WithError(err).Warning("unable to parse visible tiles")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unable to parse visible tiles: %s\n", err)
+		return
+	}
+
+	advice, err := score.Advise(&hand, visible)
+	if err != nil {
+		logger.WithError(err).Warning("unable to advise")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unable to advise: %s\n", err)
+		return
+	}
+	replyJSON(w, advice, logger)
+}
+
+// renderHand replies with an SVG image of the hand in the 'hand' query parameter,
+// which is in the compact notation.
+func (p *Pages) renderHand(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	hand, err := score.ParseHand(r.URL.Query().Get("hand"))
+	if err != nil {
+		logger.WithError(err).Warning("unable to parse hand notation")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unable to parse hand: %s\n", err)
+		return
+	}
+
+	image := bytes.Buffer{}
+	if err := render.Hand(&image, &hand); err != nil {
+		logger.WithError(err).Warning("unable to render hand")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unable to render hand: %s\n", err)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	if _, err := image.WriteTo(w); err != nil {
+		logger.WithError(err).Warning("unable to send image")
+	}
+}
+
+// SettleRequest describes the outcome of a hand, to compute the payments between the players.
+// Players are identified by their index, 0-3. Each hand is either a string in the compact
+// notation or a hand document. Instead of hands, the scores may be given directly.
+// The session and the stored IDs of the players are optional, and tell whose hands
+// are recorded.
+type SettleRequest struct {
+	RuleSet   string                       `json:"ruleset"`
+	Limit     *int                         `json:"limit"`
+	East      int                          `json:"east"`
+	Winner    int                          `json:"winner"`    // -1 when nobody won.
+	Discarder int                          `json:"discarder"` // -1 when the winning tile was self-drawn.
+	Hands     []json.RawMessage            `json:"hands"`
+	Scores    *[score.NumPlayers]int       `json:"scores"`
+	Session   storage.ID                   `json:"session"`
+	Players   [score.NumPlayers]storage.ID `json:"players"`
+}
+
+// parseHandDocument reads a hand from either a notation string or a hand document.
+func parseHandDocument(raw json.RawMessage) (score.Hand, error) {
+	if len(raw) == 0 {
+		return score.Hand{}, errors.New("no hand given")
+	}
+
+	var notation string
+	if err := json.Unmarshal(raw, &notation); err == nil {
+		return score.ParseHand(notation)
+	}
+
+	hand := score.Hand{}
+	err := json.Unmarshal(raw, &hand)
+	return hand, err
+}
+
+// scoreHands scores the hands of all players. Each player's own wind follows from
+// their seat relative to East, unless the hand says otherwise. The scored hands are
+// returned ready to be recorded.
+func scoreHands(rules *score.RuleSet, request *SettleRequest) ([score.NumPlayers]storage.Hand, error) {
+	scored := [score.NumPlayers]storage.Hand{}
+	if len(request.Hands) != score.NumPlayers {
+		return scored, fmt.Errorf("expected %d hands, not %d", score.NumPlayers, len(request.Hands))
+	}
+
+	for player, raw := range request.Hands {
+		hand, err := parseHandDocument(raw)
+		if err != nil {
+			return scored, fmt.Errorf("hand of player %d: %s", player, err)
+		}
+		if hand.WindOwn == score.NoTile {
+			seat := (player - request.East + score.NumPlayers) % score.NumPlayers
+			hand.WindOwn = score.WindEast + score.Tile(seat)
+		}
+		if player == request.Winner && request.Discarder == -1 {
+			hand.WinSelfDrawn = true
+		}
+		breakdown := rules.ScoreBreakdown(&hand)
+		scored[player] = storage.Hand{
+			Session:   request.Session,
+			Player:    request.Players[player],
+			RuleSet:   rules.Name,
+			Limit:     request.Limit,
+			Hand:      hand,
+			Score:     breakdown.Score,
+			Breakdown: breakdown,
+		}
+	}
+	return scored, nil
+}
+
+func (p *Pages) apiSettle(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	request := SettleRequest{Winner: -1, Discarder: -1}
+	if DecodeJSON(w, r.Body, &request, logger) != nil {
+		return
+	}
+
+	rules, err := p.lookupRuleSet(w, request.RuleSet, logger)
+	if err != nil {
+		return
+	}
+	if rules, err = withLimit(w, rules, request.Limit, logger); err != nil {
+		return
+	}
+	if p.checkRecordedBy(w, request.Session, request.Players[:], logger) != nil {
+		return
+	}
+
+	settlement := score.Settlement{
+		Winner:    request.Winner,
+		Discarder: request.Discarder,
+		East:      request.East,
+	}
+	var scored *[score.NumPlayers]storage.Hand
+	if request.Scores != nil {
+		settlement.Scores = *request.Scores
+	} else {
+		hands, err := scoreHands(rules, &request)
+		if err != nil {
+			logger.WithError(err).Warning("unable to score hands")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Unable to score hands: %s\n", err)
+			return
+		}
+		for player := range hands {
+			settlement.Scores[player] = hands[player].Score
+		}
+		scored = &hands
+	}
+
+	payments, err := rules.Settle(settlement)
+	if err != nil {
+		logger.WithError(err).Warning("unable to settle")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Unable to settle: %s\n", err)
+		return
+	}
+
+	replyJSON(w, &Settlement{settlement.Scores, payments, p.recordSettlement(&request, rules, scored,
+		settlement, payments, logger)}, logger)
+}
+
+func (p *Pages) apiRuleSets(w http.ResponseWriter, r *http.Request) {
+	logger := log.WithField("addr", r.RemoteAddr)
+	replyJSON(w, score.RuleSets(), logger)
+}
+
 // AddRoutes adds routes to serve reporting status requests.
 func (p *Pages) AddRoutes(router *mux.Router) {
 	router.HandleFunc("/", p.showIndexPage).Methods("GET")
 	router.HandleFunc("/score", p.showScorePage).Methods("GET")
 	router.HandleFunc("/api/random", p.apiRandom).Methods("GET")
 	router.HandleFunc("/api/calc-score", p.apiCalcScore).Methods("POST")
+	router.HandleFunc("/api/waits", p.apiWaits).Methods("POST")
+	router.HandleFunc("/api/advise", p.apiAdvise).Methods("POST")
+	router.HandleFunc("/api/rulesets", p.apiRuleSets).Methods("GET")
+	router.HandleFunc("/api/settle", p.apiSettle).Methods("POST")
+	router.HandleFunc("/render/hand.svg", p.renderHand).Methods("GET")
+	p.addHistoryRoutes(router)
+	p.addLeagueRoutes(router)
 	// router.HandleFunc("/as-json", rep.sendStatusReport).Methods("GET")
 	// router.HandleFunc("/latest-image", rep.showLatestImagePage).Methods("GET")
 	// router.HandleFunc("/worker-action/{worker-id}", rep.workerAction).Methods("POST")
@@ -115,6 +508,15 @@ func (p *Pages) showTemplate(templfname string, w http.ResponseWriter, r *http.R
 			}
 			return dict, nil
 		},
+		"percent": func(fraction float64) string {
+			return fmt.Sprintf("%.0f%%", 100*fraction)
+		},
+		"notation": func(hand score.Hand) string {
+			return score.FormatHand(&hand)
+		},
+		"inc": func(number int) int {
+			return number + 1
+		},
 	})
 
 	tmpl, err := tmpl.ParseFiles(